@@ -0,0 +1,63 @@
+// Command reencrypt-tokens rewrites every stored OAuth provider token under
+// the current token encryption key, and encrypts any still in plaintext.
+//
+// To rotate keys, add the new key to TOKEN_ENCRYPTION_KEYS, point
+// TOKEN_ENCRYPTION_KEY_ID at it and deploy, then run this command. Once it
+// finishes the old key can be removed from TOKEN_ENCRYPTION_KEYS.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/pin-app/pin/internal/config"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+func main() {
+	keyID := flag.String("key-id", "", "key to encrypt with (defaults to TOKEN_ENCRYPTION_KEY_ID)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	keys := cfg.TokenKeys
+	if keys == nil {
+		slog.Error("TOKEN_ENCRYPTION_KEYS must be set")
+		os.Exit(1)
+	}
+	if *keyID != "" {
+		if keys, err = keys.WithPrimary(*keyID); err != nil {
+			slog.Error("invalid -key-id", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if cfg.DatabaseURL == "" {
+		slog.Error("DATABASE_URL must be set")
+		os.Exit(1)
+	}
+	db, err := database.New(cfg.DatabaseURL)
+	if err != nil {
+		slog.Error("failed to open database connection", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	slog.Info("re-encrypting OAuth tokens", "key_id", keys.PrimaryKeyID())
+
+	updated, err := repository.NewOAuthRepository(db, keys).ReencryptTokens(context.Background())
+	if err != nil {
+		slog.Error("failed to re-encrypt OAuth tokens", "error", err, "updated", updated)
+		db.Close()
+		os.Exit(1)
+	}
+
+	slog.Info("re-encrypted OAuth tokens", "updated", updated)
+}
@@ -5,10 +5,14 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
+	"github.com/pin-app/pin/internal/config"
 	"github.com/pin-app/pin/internal/database"
 	"github.com/pin-app/pin/internal/handlers"
+	"github.com/pin-app/pin/internal/jobs"
 	"github.com/pin-app/pin/internal/repository"
 	"github.com/pin-app/pin/internal/seed"
 	"github.com/pin-app/pin/internal/server"
@@ -16,48 +20,51 @@ import (
 )
 
 func main() {
-	uploadDir := os.Getenv("UPLOAD_DIR")
-	if uploadDir == "" {
-		uploadDir = "uploads"
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
 	}
-	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+
+	if err := os.MkdirAll(cfg.UploadDir, 0o755); err != nil {
 		slog.Error("failed to prepare upload directory", "error", err)
 		os.Exit(1)
 	}
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// exports hold personal data, so keep them out of the public upload dir
+	if err := os.MkdirAll(cfg.ExportDir, 0o700); err != nil {
+		slog.Error("failed to prepare export directory", "error", err)
+		os.Exit(1)
 	}
 
-	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
-		slog.Error("invalid PORT environment variable; must be 1-65535",
-			"PORT", port,
-			"error", err,
+	devMode := cfg.DevMode.Enabled
+	if devMode {
+		slog.Warn("!!! DEV_MODE IS ON - AUTHENTICATION IS BYPASSED FOR LOCAL REQUESTS - NEVER RUN THIS ON A REACHABLE HOST !!!",
+			"allowed", cfg.DevMode.AllowedNetworks,
 		)
-		os.Exit(1)
 	}
 
-	devMode := os.Getenv("DEV_MODE") == "true"
-	if devMode {
-		slog.Info("running in development mode - authentication bypassed for dev users")
+	if cfg.TokenKeys == nil {
+		slog.Warn("TOKEN_ENCRYPTION_KEYS is not set - OAuth provider tokens will be stored unencrypted")
+	}
+
+	if cfg.MetricsToken == "" {
+		slog.Warn("METRICS_TOKEN is not set - /metrics is not served, so scrapers will get 404")
 	}
 
 	var db *database.DB
-	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
+	if cfg.DatabaseURL != "" {
 		slog.Info("running database migrations")
-		if err := migrations.Run(dbURL); err != nil {
+		if err := migrations.Run(cfg.DatabaseURL); err != nil {
 			slog.Error("database migration failed", "error", err)
 			os.Exit(1)
 		}
 		slog.Info("database migrations complete")
 
-		var err error
-		db, err = database.New(dbURL)
+		db, err = database.New(cfg.DatabaseURL)
 		if err != nil {
 			slog.Error("failed to open database connection", "error", err)
 			os.Exit(1)
 		}
-		defer db.Close()
 	}
 
 	if devMode && db == nil {
@@ -100,20 +107,71 @@ func main() {
 		srv = server.New()
 	}
 
-	srv.ServeStatic("/uploads/", uploadDir)
+	srv.ServeStatic("/uploads/", cfg.UploadDir)
+	srv.ServeMetrics(cfg.MetricsToken)
+
+	if err := handlers.RegisterRoutes(srv, db, cfg); err != nil {
+		slog.Error("failed to set up routes", "error", err)
+		os.Exit(1)
+	}
+
+	var scheduler *jobs.Scheduler
+	if db != nil {
+		scheduler = jobs.New(jobs.NewAdvisoryLocker(db.GetConnection()))
+		jobs.RegisterMaintenance(scheduler, db, cfg.UploadDir, cfg.ExportDir, cfg.SoftDeleteRetention)
+		jobs.RegisterExports(scheduler, db, cfg.UploadDir, cfg.ExportDir)
+		jobs.RegisterMetrics(scheduler)
+		scheduler.Start(context.Background())
+	}
+
+	httpServer := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Port),
+		Handler:           srv,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
 
-	handlers.RegisterRoutes(srv, db, uploadDir)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	slog.Info("server starting",
-		"port", port,
-		"service", "pin",
-	)
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting",
+			"port", cfg.Port,
+			"service", "pin",
+		)
+		serveErr <- httpServer.ListenAndServe()
+	}()
 
-	if err := http.ListenAndServe(":"+port, srv); err != nil {
+	exitCode := 0
+	select {
+	case err := <-serveErr:
 		slog.Error("server failed to start",
 			"error", err,
-			"port", port,
+			"port", cfg.Port,
 		)
-		os.Exit(1)
+		exitCode = 1
+	case <-ctx.Done():
+		// a second signal kills the process straight away
+		stop()
+		slog.Info("shutting down, draining requests", "timeout", cfg.HTTP.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("server didn't shut down cleanly", "error", err)
+			exitCode = 1
+		}
+		cancel()
+	}
+
+	// jobs may still be using the database, so stop them before closing it
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	if db != nil {
+		db.Close()
 	}
+	slog.Info("server stopped")
+	os.Exit(exitCode)
 }
@@ -0,0 +1,407 @@
+// Package config loads every setting the server reads from the environment,
+// plus an optional file named by CONFIG_FILE, and checks them all before
+// anything starts. Other packages take the typed values from Config rather
+// than reading the environment themselves.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pin-app/pin/internal/jobs"
+	"github.com/pin-app/pin/internal/mail"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/oauth"
+	"github.com/pin-app/pin/internal/ratelimit"
+	"github.com/pin-app/pin/internal/secrets"
+)
+
+// Config holds the settings the server needs to start
+type Config struct {
+	Port        int
+	DatabaseURL string
+	// UploadDir is served publicly under /uploads/
+	UploadDir string
+	// ExportDir holds personal data exports, so it's kept apart from
+	// UploadDir
+	ExportDir           string
+	MagicLinkURL        string
+	SoftDeleteRetention time.Duration
+	HTTP                HTTPConfig
+
+	// Production is set when APP_ENV is production or prod
+	Production bool
+	DevMode    middleware.DevMode
+	OAuth      OAuthConfig
+	Mail       mail.Config
+	// RateLimits has a policy for every ratelimit group
+	RateLimits map[string]ratelimit.Policy
+	// TokenKeys seal stored OAuth provider tokens; nil stores them as is
+	TokenKeys *secrets.Keyring
+	// MetricsToken is the bearer token /metrics asks for; without one
+	// /metrics isn't served
+	MetricsToken string
+}
+
+// HTTPConfig bounds how long a connection may take over each part of a
+// request, and how long shutdown waits for requests in flight
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+// OAuthConfig holds the sign in providers and the app URLs their callbacks
+// may hand off to
+type OAuthConfig struct {
+	Providers         []oauth.ProviderConfig
+	RedirectAllowlist oauth.RedirectAllowlist
+}
+
+// Default is what Load starts from
+var Default = Config{
+	Port:                8080,
+	UploadDir:           "uploads",
+	ExportDir:           "exports",
+	MagicLinkURL:        "pin://auth/magic-link",
+	SoftDeleteRetention: jobs.DefaultSoftDeleteRetention,
+	HTTP: HTTPConfig{
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+	},
+	Mail: mail.Config{
+		Driver: mail.DriverFile,
+		SMTP:   mail.SMTPConfig{Port: 587},
+		Dir:    "mail",
+	},
+}
+
+// Load reads the environment, and CONFIG_FILE if set, and reports every
+// invalid value at once
+func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return LoadFile(path)
+	}
+	return load(os.Getenv)
+}
+
+// LoadFile is Load with settings from a file of KEY=VALUE lines. Blank lines
+// and lines starting with # are skipped, and values may be quoted. Variables
+// already in the environment win over the file.
+func LoadFile(path string) (*Config, error) {
+	file, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return load(func(key string) string {
+		if value, set := os.LookupEnv(key); set {
+			return value
+		}
+		return file[key]
+	})
+}
+
+// loader reads settings through env, collecting every problem it finds
+type loader struct {
+	env  func(key string) string
+	errs []error
+}
+
+func load(env func(key string) string) (*Config, error) {
+	l := &loader{env: env}
+	cfg := Default
+
+	if raw := env("PORT"); raw != "" {
+		cfg.Port = l.port("PORT", raw)
+	}
+
+	cfg.DatabaseURL = env("DATABASE_URL")
+	cfg.MetricsToken = env("METRICS_TOKEN")
+	l.setString(&cfg.UploadDir, "UPLOAD_DIR")
+	l.setString(&cfg.ExportDir, "EXPORT_DIR")
+	l.setString(&cfg.MagicLinkURL, "MAGIC_LINK_URL")
+
+	l.setDuration(&cfg.SoftDeleteRetention, "SOFT_DELETE_RETENTION")
+	l.setDuration(&cfg.HTTP.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT")
+	l.setDuration(&cfg.HTTP.ReadTimeout, "HTTP_READ_TIMEOUT")
+	l.setDuration(&cfg.HTTP.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	l.setDuration(&cfg.HTTP.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	l.setDuration(&cfg.HTTP.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	if cfg.UploadDir == cfg.ExportDir {
+		l.fail(errors.New("EXPORT_DIR must differ from UPLOAD_DIR, which is served publicly"))
+	}
+
+	switch strings.ToLower(strings.TrimSpace(env("APP_ENV"))) {
+	case "production", "prod":
+		cfg.Production = true
+	}
+	cfg.DevMode = l.devMode(cfg.Production)
+	cfg.OAuth = l.oauth()
+	cfg.Mail = l.mail(cfg.Mail)
+	cfg.RateLimits = l.rateLimits()
+
+	keys, err := secrets.ParseKeyring(env("TOKEN_ENCRYPTION_KEYS"), env("TOKEN_ENCRYPTION_KEY_ID"))
+	if err != nil {
+		l.fail(fmt.Errorf("invalid TOKEN_ENCRYPTION_KEYS: %w", err))
+	}
+	cfg.TokenKeys = keys
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (l *loader) fail(err error) {
+	l.errs = append(l.errs, err)
+}
+
+func (l *loader) setString(dst *string, key string) {
+	if value := l.env(key); value != "" {
+		*dst = value
+	}
+}
+
+func (l *loader) setDuration(dst *time.Duration, key string) {
+	raw := l.env(key)
+	if raw == "" {
+		return
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		l.fail(fmt.Errorf("invalid %s %q; must be a positive duration like 30s", key, raw))
+		return
+	}
+	*dst = d
+}
+
+func (l *loader) port(key, raw string) int {
+	port, err := strconv.Atoi(raw)
+	if err != nil || port <= 0 || port > 65535 {
+		l.fail(fmt.Errorf("invalid %s %q; must be 1-65535", key, raw))
+	}
+	return port
+}
+
+// devMode reads DEV_MODE and DEV_MODE_ALLOWED_IPS. The bypass is never
+// allowed in production.
+func (l *loader) devMode(production bool) middleware.DevMode {
+	var dev middleware.DevMode
+	if raw := l.env("DEV_MODE"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			l.fail(fmt.Errorf("invalid DEV_MODE %q; must be true or false", raw))
+		}
+		dev.Enabled = enabled
+	}
+	if dev.Enabled && production {
+		l.fail(errors.New("DEV_MODE cannot be enabled when APP_ENV is production"))
+	}
+
+	networks, err := middleware.ParseDevNetworks(l.env("DEV_MODE_ALLOWED_IPS"))
+	if err != nil {
+		l.fail(fmt.Errorf("invalid DEV_MODE_ALLOWED_IPS: %w", err))
+	}
+	dev.AllowedNetworks = networks
+	return dev
+}
+
+// oauth reads the sign in providers. Google and Apple keep their original
+// GOOGLE_* / APPLE_* variables; anything else is listed in OAUTH_PROVIDERS
+// and configured with OAUTH_<NAME>_* variables. Providers without a client
+// ID are skipped.
+func (l *loader) oauth() OAuthConfig {
+	var cfg OAuthConfig
+
+	if id := l.env("GOOGLE_CLIENT_ID"); id != "" {
+		cfg.Providers = append(cfg.Providers, oauth.ProviderConfig{
+			Name:         "google",
+			Type:         oauth.TypeOIDC,
+			ClientID:     id,
+			ClientSecret: l.env("GOOGLE_CLIENT_SECRET"),
+			RedirectURL:  l.env("GOOGLE_REDIRECT_URL"),
+			Issuer:       "https://accounts.google.com",
+			JWKSURL:      l.env("GOOGLE_JWKS_URL"),
+		})
+	}
+
+	if id := l.env("APPLE_CLIENT_ID"); id != "" {
+		privateKey := l.env("APPLE_PRIVATE_KEY")
+		if path := l.env("APPLE_PRIVATE_KEY_PATH"); privateKey == "" && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				l.fail(fmt.Errorf("invalid APPLE_PRIVATE_KEY_PATH: %w", err))
+			}
+			privateKey = string(data)
+		}
+		cfg.Providers = append(cfg.Providers, oauth.ProviderConfig{
+			Name:         "apple",
+			Type:         oauth.TypeApple,
+			ClientID:     id,
+			ClientSecret: l.env("APPLE_CLIENT_SECRET"),
+			RedirectURL:  l.env("APPLE_REDIRECT_URL"),
+			JWKSURL:      l.env("APPLE_JWKS_URL"),
+			TeamID:       l.env("APPLE_TEAM_ID"),
+			KeyID:        l.env("APPLE_KEY_ID"),
+			PrivateKey:   privateKey,
+		})
+	}
+
+	for _, name := range strings.Split(l.env("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		env := func(key string) string {
+			return l.env(prefix + key)
+		}
+
+		provider := oauth.ProviderConfig{
+			Name:         name,
+			Type:         env("TYPE"),
+			ClientID:     env("CLIENT_ID"),
+			ClientSecret: env("CLIENT_SECRET"),
+			RedirectURL:  env("REDIRECT_URL"),
+			Issuer:       env("ISSUER"),
+			JWKSURL:      env("JWKS_URL"),
+			AuthURL:      env("AUTH_URL"),
+			TokenURL:     env("TOKEN_URL"),
+			UserInfoURL:  env("USERINFO_URL"),
+			IDField:      env("ID_FIELD"),
+			EmailField:   env("EMAIL_FIELD"),
+			NameField:    env("NAME_FIELD"),
+		}
+		if provider.Type == "" {
+			provider.Type = oauth.TypeOIDC
+		}
+		if scopes := env("SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+		if provider.ClientID == "" {
+			continue
+		}
+
+		cfg.Providers = append(cfg.Providers, provider)
+	}
+
+	for _, provider := range cfg.Providers {
+		if err := provider.Validate(); err != nil {
+			l.fail(fmt.Errorf("invalid OAuth provider: %w", err))
+		}
+	}
+
+	allowlist, err := oauth.ParseRedirectAllowlist(l.env("OAUTH_REDIRECT_ALLOWLIST"))
+	if err != nil {
+		l.fail(fmt.Errorf("invalid OAUTH_REDIRECT_ALLOWLIST: %w", err))
+	}
+	cfg.RedirectAllowlist = allowlist
+
+	return cfg
+}
+
+// mail picks the driver from MAIL_DRIVER: "smtp", "file" or "memory". It
+// defaults to smtp when SMTP_HOST is set and to writing files otherwise, so
+// local runs never need a mail server.
+func (l *loader) mail(cfg mail.Config) mail.Config {
+	cfg.SMTP.Host = l.env("SMTP_HOST")
+	cfg.SMTP.Username = l.env("SMTP_USERNAME")
+	cfg.SMTP.Password = l.env("SMTP_PASSWORD")
+	cfg.SMTP.From = l.env("MAIL_FROM")
+	if raw := l.env("SMTP_PORT"); raw != "" {
+		cfg.SMTP.Port = l.port("SMTP_PORT", raw)
+	}
+	l.setString(&cfg.Dir, "MAIL_DIR")
+
+	cfg.Driver = strings.ToLower(l.env("MAIL_DRIVER"))
+	if cfg.Driver == "" {
+		cfg.Driver = mail.DriverFile
+		if cfg.SMTP.Host != "" {
+			cfg.Driver = mail.DriverSMTP
+		}
+	}
+
+	switch cfg.Driver {
+	case mail.DriverSMTP:
+		if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+			l.fail(errors.New("SMTP_HOST and MAIL_FROM are required to send mail over SMTP"))
+		}
+	case mail.DriverFile, mail.DriverMemory:
+	default:
+		l.fail(fmt.Errorf("invalid MAIL_DRIVER %q; must be smtp, file or memory", cfg.Driver))
+	}
+	return cfg
+}
+
+// rateLimits starts from ratelimit.DefaultPolicies and overrides any group
+// set in RATE_LIMIT_<GROUP>, written as limit/duration with an optional
+// burst, e.g. RATE_LIMIT_AUTH=60/1m or RATE_LIMIT_SIGNUP=10/1h:20
+func (l *loader) rateLimits() map[string]ratelimit.Policy {
+	policies := make(map[string]ratelimit.Policy, len(ratelimit.DefaultPolicies))
+	for group, policy := range ratelimit.DefaultPolicies {
+		policies[group] = policy
+
+		key := "RATE_LIMIT_" + strings.ToUpper(group)
+		raw := l.env(key)
+		if raw == "" {
+			continue
+		}
+		parsed, err := ratelimit.ParsePolicy(raw)
+		if err != nil {
+			l.fail(fmt.Errorf("invalid %s: %w", key, err))
+			continue
+		}
+		policies[group] = parsed
+	}
+	return policies
+}
+
+// readFile reads a file of KEY=VALUE lines into a map
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: want KEY=VALUE", path, n)
+		}
+		values[key] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return values, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pin-app/pin/internal/mail"
+	"github.com/pin-app/pin/internal/ratelimit"
+	"github.com/pin-app/pin/internal/secrets"
+)
+
+// unsetEnv clears keys for the test and puts them back afterwards
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+var keys = []string{
+	"CONFIG_FILE", "PORT", "DATABASE_URL", "UPLOAD_DIR", "EXPORT_DIR", "MAGIC_LINK_URL",
+	"SOFT_DELETE_RETENTION", "HTTP_READ_HEADER_TIMEOUT", "HTTP_READ_TIMEOUT",
+	"HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT",
+	"APP_ENV", "DEV_MODE", "DEV_MODE_ALLOWED_IPS",
+	"GOOGLE_CLIENT_ID", "APPLE_CLIENT_ID", "OAUTH_PROVIDERS", "OAUTH_REDIRECT_ALLOWLIST",
+	"MAIL_DRIVER", "SMTP_HOST", "SMTP_PORT", "MAIL_FROM", "MAIL_DIR",
+	"RATE_LIMIT_AUTH", "RATE_LIMIT_AUTH_TOKEN", "RATE_LIMIT_SIGNUP", "RATE_LIMIT_EXPORT",
+	"TOKEN_ENCRYPTION_KEYS", "TOKEN_ENCRYPTION_KEY_ID", "METRICS_TOKEN",
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	unsetEnv(t, keys...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != Default.Port || cfg.UploadDir != Default.UploadDir || cfg.HTTP != Default.HTTP {
+		t.Errorf("Load() = %+v, want the defaults", cfg)
+	}
+	if cfg.Mail != Default.Mail || cfg.DevMode.Enabled || cfg.TokenKeys != nil {
+		t.Errorf("Load() = %+v, want the defaults", cfg)
+	}
+	if cfg.RateLimits[ratelimit.GroupAuth] != ratelimit.DefaultPolicies[ratelimit.GroupAuth] {
+		t.Errorf("auth policy = %+v, want the default", cfg.RateLimits[ratelimit.GroupAuth])
+	}
+}
+
+func TestLoad_Env(t *testing.T) {
+	unsetEnv(t, keys...)
+	t.Setenv("PORT", "9000")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "2m")
+	t.Setenv("UPLOAD_DIR", "/srv/uploads")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("Port = %d, want 9000", cfg.Port)
+	}
+	if cfg.HTTP.WriteTimeout != 2*time.Minute {
+		t.Errorf("WriteTimeout = %v, want 2m", cfg.HTTP.WriteTimeout)
+	}
+	if cfg.UploadDir != "/srv/uploads" {
+		t.Errorf("UploadDir = %q, want /srv/uploads", cfg.UploadDir)
+	}
+}
+
+func TestLoad_Settings(t *testing.T) {
+	unsetEnv(t, keys...)
+	t.Setenv("DEV_MODE", "true")
+	t.Setenv("DEV_MODE_ALLOWED_IPS", "10.1.0.0/16")
+	t.Setenv("GOOGLE_CLIENT_ID", "google-id")
+	t.Setenv("OAUTH_REDIRECT_ALLOWLIST", "pin://auth/callback")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("MAIL_FROM", "pin@example.com")
+	t.Setenv("RATE_LIMIT_SIGNUP", "10/1h")
+	t.Setenv("TOKEN_ENCRYPTION_KEYS", "k1:"+base64.StdEncoding.EncodeToString(make([]byte, secrets.KeySize)))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.DevMode.Enabled || len(cfg.DevMode.AllowedNetworks) != 3 {
+		t.Errorf("DevMode = %+v, want loopback and 10.1.0.0/16", cfg.DevMode)
+	}
+	if len(cfg.OAuth.Providers) != 1 || cfg.OAuth.Providers[0].Name != "google" {
+		t.Errorf("Providers = %+v, want google", cfg.OAuth.Providers)
+	}
+	if !cfg.OAuth.RedirectAllowlist.Allowed("pin://auth/callback") {
+		t.Errorf("RedirectAllowlist = %v", cfg.OAuth.RedirectAllowlist)
+	}
+	if cfg.Mail.Driver != mail.DriverSMTP || cfg.Mail.SMTP.Port != 587 {
+		t.Errorf("Mail = %+v, want smtp on port 587", cfg.Mail)
+	}
+	if cfg.RateLimits[ratelimit.GroupSignup] != (ratelimit.Policy{Limit: 10, Per: time.Hour}) {
+		t.Errorf("signup policy = %+v", cfg.RateLimits[ratelimit.GroupSignup])
+	}
+	if cfg.TokenKeys == nil || cfg.TokenKeys.PrimaryKeyID() != "k1" {
+		t.Errorf("TokenKeys = %v, want k1", cfg.TokenKeys)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	unsetEnv(t, keys...)
+	t.Setenv("PORT", "70000")
+	t.Setenv("SHUTDOWN_TIMEOUT", "-1s")
+	t.Setenv("EXPORT_DIR", "uploads")
+	t.Setenv("DEV_MODE_ALLOWED_IPS", "10.0.0.0/33")
+	t.Setenv("OAUTH_PROVIDERS", "corp")
+	t.Setenv("OAUTH_CORP_CLIENT_ID", "corp-id")
+	t.Setenv("OAUTH_REDIRECT_ALLOWLIST", "not a url")
+	t.Setenv("MAIL_DRIVER", "pigeon")
+	t.Setenv("RATE_LIMIT_AUTH", "lots")
+	t.Setenv("TOKEN_ENCRYPTION_KEYS", "k1")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() accepted invalid values")
+	}
+	// every problem is reported, not just the first
+	for _, want := range []string{
+		"PORT", "SHUTDOWN_TIMEOUT", "EXPORT_DIR", "DEV_MODE_ALLOWED_IPS", "corp: issuer",
+		"OAUTH_REDIRECT_ALLOWLIST", "MAIL_DRIVER", "RATE_LIMIT_AUTH", "TOKEN_ENCRYPTION_KEYS",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error %q doesn't mention %s", err, want)
+		}
+	}
+}
+
+func TestLoad_DevModeInProduction(t *testing.T) {
+	tests := []struct {
+		devMode string
+		appEnv  string
+		wantErr bool
+	}{
+		{"false", "production", false},
+		{"true", "development", false},
+		{"true", "production", true},
+		{"1", "Prod", true},
+		{"maybe", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.devMode+" in "+tt.appEnv, func(t *testing.T) {
+			unsetEnv(t, keys...)
+			t.Setenv("DEV_MODE", tt.devMode)
+			t.Setenv("APP_ENV", tt.appEnv)
+
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	unsetEnv(t, keys...)
+	path := filepath.Join(t.TempDir(), "pin.env")
+	content := `# local settings
+PORT=9100
+export MAGIC_LINK_URL="https://pin.app/magic"
+UPLOAD_DIR=/from/file
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("UPLOAD_DIR", "/from/env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != 9100 {
+		t.Errorf("Port = %d, want 9100 from the file", cfg.Port)
+	}
+	if cfg.MagicLinkURL != "https://pin.app/magic" {
+		t.Errorf("MagicLinkURL = %q, want the unquoted file value", cfg.MagicLinkURL)
+	}
+	if cfg.UploadDir != "/from/env" {
+		t.Errorf("UploadDir = %q, want the environment to win over the file", cfg.UploadDir)
+	}
+	if _, set := os.LookupEnv("PORT"); set {
+		t.Error("Load() copied the file into the environment")
+	}
+}
+
+func TestLoadFile_Malformed(t *testing.T) {
+	unsetEnv(t, keys...)
+	path := filepath.Join(t.TempDir(), "pin.env")
+	if err := os.WriteFile(path, []byte("PORT 9100\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile() accepted a line without =")
+	}
+}
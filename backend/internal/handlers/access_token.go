@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+)
+
+const (
+	defaultAccessTokenDays = 90
+	// how many trailing characters of a token are kept to tell it apart
+	accessTokenHintLength = 4
+)
+
+type AccessTokenHandler struct {
+	tokenRepo repository.AccessTokenRepository
+	validator *validator.Validate
+}
+
+func NewAccessTokenHandler(tokenRepo repository.AccessTokenRepository) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenRepo: tokenRepo,
+		validator: validator.New(),
+	}
+}
+
+// ListTokens returns the caller's personal access tokens, without the tokens
+// themselves
+func (h *AccessTokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	tokens, err := h.tokenRepo.ListByUserID(r.Context(), userID)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get access tokens"})
+		return
+	}
+
+	responses := make([]models.AccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		responses[i] = token.ToResponse()
+	}
+
+	server.WriteJSON(w, http.StatusOK, responses)
+}
+
+// CreateToken issues a personal access token. The response is the only time
+// the token is shown.
+func (h *AccessTokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.AccessTokenCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	scopes := make([]models.Scope, 0, len(req.Scopes))
+	seen := make(map[models.Scope]bool)
+	for _, scope := range req.Scopes {
+		if !scope.IsValid() {
+			server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Unknown scope %q", scope)})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	days := defaultAccessTokenDays
+	if req.ExpiresInDays != nil {
+		days = *req.ExpiresInDays
+	}
+
+	raw, err := middleware.GenerateAccessToken()
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create access token"})
+		return
+	}
+
+	now := time.Now()
+	token := &models.AccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Scopes:    scopes,
+		Token:     raw,
+		TokenHint: raw[len(raw)-accessTokenHintLength:],
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+
+	if err := h.tokenRepo.Create(r.Context(), token); err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create access token"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusCreated, token.ToResponse())
+}
+
+// RevokeToken deletes one of the caller's personal access tokens
+func (h *AccessTokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid token ID"})
+		return
+	}
+
+	if err := h.tokenRepo.DeleteForUser(r.Context(), id, userID); err != nil {
+		if errors.Is(err, repository.ErrAccessTokenNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Access token not found"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to revoke access token"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockAccessTokenRepository is a mock implementation of AccessTokenRepository for testing
+type MockAccessTokenRepository struct {
+	tokens map[uuid.UUID]*models.AccessToken
+}
+
+func NewMockAccessTokenRepository() *MockAccessTokenRepository {
+	return &MockAccessTokenRepository{tokens: make(map[uuid.UUID]*models.AccessToken)}
+}
+
+func (m *MockAccessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockAccessTokenRepository) GetByToken(ctx context.Context, token string) (*models.AccessToken, error) {
+	for _, t := range m.tokens {
+		if t.Token == token && t.ExpiresAt.After(time.Now()) {
+			return t, nil
+		}
+	}
+	return nil, repository.ErrAccessTokenNotFound
+}
+
+func (m *MockAccessTokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.AccessToken, error) {
+	var tokens []*models.AccessToken
+	for _, t := range m.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockAccessTokenRepository) DeleteForUser(ctx context.Context, id, userID uuid.UUID) error {
+	t, ok := m.tokens[id]
+	if !ok || t.UserID != userID {
+		return repository.ErrAccessTokenNotFound
+	}
+	delete(m.tokens, id)
+	return nil
+}
+
+func (m *MockAccessTokenRepository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	if t, ok := m.tokens[id]; ok {
+		t.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+func TestAccessTokenHandler_CreateToken(t *testing.T) {
+	tokenRepo := NewMockAccessTokenRepository()
+	handler := NewAccessTokenHandler(tokenRepo)
+	userID := uuid.New()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", `{"name": "backup script", "scopes": ["posts:write", "ratings:read"]}`, http.StatusCreated},
+		{"unknown scope", `{"name": "backup script", "scopes": ["admin"]}`, http.StatusBadRequest},
+		{"no scopes", `{"name": "backup script", "scopes": []}`, http.StatusBadRequest},
+		{"too long", `{"name": "backup script", "scopes": ["posts:write"], "expires_in_days": 1000}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withUser(httptest.NewRequest("POST", "/api/tokens", bytes.NewBufferString(tt.body)), userID)
+			w := httptest.NewRecorder()
+
+			handler.CreateToken(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var resp models.AccessTokenResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !strings.HasPrefix(resp.Token, models.AccessTokenPrefix) {
+				t.Errorf("expected token with prefix %q, got %q", models.AccessTokenPrefix, resp.Token)
+			}
+			if !strings.HasSuffix(resp.Token, resp.TokenHint) {
+				t.Errorf("token hint %q does not match token", resp.TokenHint)
+			}
+			if want := time.Now().AddDate(0, 0, defaultAccessTokenDays); resp.ExpiresAt.Sub(want).Abs() > time.Minute {
+				t.Errorf("expected expiry around %v, got %v", want, resp.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_AccessTokenScopes(t *testing.T) {
+	t.Setenv("DEV_MODE", "false")
+
+	userRepo := NewMockUserRepository()
+	tokenRepo := NewMockAccessTokenRepository()
+	authMW := middleware.NewAuthMiddleware(NewMockSessionRepository(), userRepo, tokenRepo, middleware.DevMode{})
+
+	user := &models.User{ID: uuid.New(), Email: "script@example.com"}
+	userRepo.Create(context.Background(), user)
+	token := &models.AccessToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Scopes:    []models.Scope{models.ScopeRatingsRead},
+		Token:     models.AccessTokenPrefix + "abc",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tokenRepo.Create(context.Background(), token)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if id, _ := middleware.GetUserIDFromContext(r.Context()); id != user.ID {
+			t.Errorf("expected user %s in context, got %s", user.ID, id)
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		token      string
+		wantStatus int
+	}{
+		{"granted scope", authMW.RequireScope(models.ScopeRatingsRead, ok), token.Token, http.StatusOK},
+		{"missing scope", authMW.RequireScope(models.ScopePostsWrite, ok), token.Token, http.StatusForbidden},
+		{"session only route", authMW.RequireAuth(ok), token.Token, http.StatusForbidden},
+		{"unknown token", authMW.RequireScope(models.ScopeRatingsRead, ok), models.AccessTokenPrefix + "nope", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if token.LastUsedAt == nil {
+		t.Error("expected last_used_at to be recorded")
+	}
+}
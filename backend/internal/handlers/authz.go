@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/server"
+)
+
+// authorize makes sure the caller owns the resource or holds the override
+// role, writing a 401 or 403 response when they don't. Mutating handlers
+// should call this before touching the repository.
+func authorize(w http.ResponseWriter, r *http.Request, ownerID uuid.UUID, override models.UserRole) (uuid.UUID, bool) {
+	userID, err := middleware.Authorize(r.Context(), ownerID, override)
+	if err != nil {
+		writeAuthzError(w, err)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeAuthzError(w http.ResponseWriter, err error) {
+	if errors.Is(err, middleware.ErrNotAuthenticated) {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+	server.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to modify this resource"})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+)
+
+// BlockHandler blocks and mutes other users. The repositories apply both to
+// every query, so this only manages the relationships themselves.
+type BlockHandler struct {
+	blockRepo repository.BlockRepository
+	userRepo  repository.UserRepository
+}
+
+func NewBlockHandler(blockRepo repository.BlockRepository, userRepo repository.UserRepository) *BlockHandler {
+	return &BlockHandler{
+		blockRepo: blockRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func (h *BlockHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	currentUserID, userID, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.blockRepo.Block(r.Context(), currentUserID, userID); err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to block user"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *BlockHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	currentUserID, userID, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.blockRepo.Unblock(r.Context(), currentUserID, userID); err != nil {
+		if errors.Is(err, repository.ErrBlockNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User is not blocked"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to unblock user"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *BlockHandler) MuteUser(w http.ResponseWriter, r *http.Request) {
+	currentUserID, userID, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.blockRepo.Mute(r.Context(), currentUserID, userID); err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to mute user"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *BlockHandler) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	currentUserID, userID, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.blockRepo.Unmute(r.Context(), currentUserID, userID); err != nil {
+		if errors.Is(err, repository.ErrMuteNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User is not muted"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to unmute user"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *BlockHandler) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.blockRepo.ListBlocked, "Failed to list blocked users")
+}
+
+func (h *BlockHandler) ListMuted(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.blockRepo.ListMuted, "Failed to list muted users")
+}
+
+// target resolves the caller and the {id} user, writing the error response
+// itself when either is missing
+func (h *BlockHandler) target(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	currentUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if currentUserID == userID {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Cannot block or mute yourself"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := h.userRepo.GetByID(r.Context(), userID); err != nil {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return currentUserID, userID, true
+}
+
+func (h *BlockHandler) list(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error), failure string) {
+	currentUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	offset := 0
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	users, err := list(r.Context(), currentUserID, limit, offset)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": failure})
+		return
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+	}
+
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"users":  responses,
+		"limit":  limit,
+		"offset": offset,
+		"count":  len(responses),
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockBlockRepository is a mock implementation of BlockRepository for testing
+type MockBlockRepository struct {
+	blocks map[followKey]bool
+	mutes  map[followKey]bool
+}
+
+func NewMockBlockRepository() *MockBlockRepository {
+	return &MockBlockRepository{
+		blocks: make(map[followKey]bool),
+		mutes:  make(map[followKey]bool),
+	}
+}
+
+func (m *MockBlockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	m.blocks[followKey{blockerID, blockedID}] = true
+	return nil
+}
+
+func (m *MockBlockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	key := followKey{blockerID, blockedID}
+	if !m.blocks[key] {
+		return repository.ErrBlockNotFound
+	}
+	delete(m.blocks, key)
+	return nil
+}
+
+func (m *MockBlockRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	return m.blocks[followKey{userID, otherID}] || m.blocks[followKey{otherID, userID}], nil
+}
+
+func (m *MockBlockRepository) ListBlocked(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	var users []*models.User
+	for key := range m.blocks {
+		if key.followerID == userID {
+			users = append(users, &models.User{ID: key.followingID})
+		}
+	}
+	return users, nil
+}
+
+func (m *MockBlockRepository) Mute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	m.mutes[followKey{muterID, mutedID}] = true
+	return nil
+}
+
+func (m *MockBlockRepository) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	key := followKey{muterID, mutedID}
+	if !m.mutes[key] {
+		return repository.ErrMuteNotFound
+	}
+	delete(m.mutes, key)
+	return nil
+}
+
+func (m *MockBlockRepository) ListMuted(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	return nil, nil
+}
+
+func TestBlockHandler_BlockUser(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	blockRepo := NewMockBlockRepository()
+	handler := NewBlockHandler(blockRepo, userRepo)
+
+	blockerID := uuid.New()
+	blockedID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: blockedID, Email: "blocked@example.com"})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+blockedID.String()+"/block", nil), "id", blockedID.String())
+	req = withUser(req, blockerID)
+	rr := httptest.NewRecorder()
+	handler.BlockUser(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("BlockUser() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if blocked, _ := blockRepo.IsBlocked(context.Background(), blockedID, blockerID); !blocked {
+		t.Errorf("BlockUser() didn't block the user")
+	}
+
+	req = withPathValue(httptest.NewRequest("DELETE", "/api/users/"+blockedID.String()+"/block", nil), "id", blockedID.String())
+	req = withUser(req, blockerID)
+	rr = httptest.NewRecorder()
+	handler.UnblockUser(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("UnblockUser() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.UnblockUser(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("UnblockUser() status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestBlockHandler_BlockUser_Self(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	handler := NewBlockHandler(NewMockBlockRepository(), userRepo)
+
+	userID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com"})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+userID.String()+"/block", nil), "id", userID.String())
+	req = withUser(req, userID)
+	rr := httptest.NewRecorder()
+	handler.BlockUser(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("BlockUser() status = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestBlockHandler_MuteUser(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	blockRepo := NewMockBlockRepository()
+	handler := NewBlockHandler(blockRepo, userRepo)
+
+	muterID := uuid.New()
+	mutedID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: mutedID, Email: "muted@example.com"})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+mutedID.String()+"/mute", nil), "id", mutedID.String())
+	req = withUser(req, muterID)
+	rr := httptest.NewRecorder()
+	handler.MuteUser(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("MuteUser() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if !blockRepo.mutes[followKey{muterID, mutedID}] {
+		t.Errorf("MuteUser() didn't mute the user")
+	}
+	if blocked, _ := blockRepo.IsBlocked(context.Background(), muterID, mutedID); blocked {
+		t.Errorf("MuteUser() blocked the user")
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -52,7 +53,8 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.postRepo.GetByID(r.Context(), req.PostID)
+	// hidden posts and comments can't be replied to
+	post, err := h.postRepo.GetVisibleByID(r.Context(), req.PostID, userID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Post not found"})
 		return
@@ -60,7 +62,7 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 
 	var parentComment *models.Comment
 	if req.ParentID != nil {
-		parentComment, err = h.commentRepo.GetByID(r.Context(), *req.ParentID)
+		parentComment, err = h.commentRepo.GetVisibleByID(r.Context(), *req.ParentID, userID)
 		if err != nil {
 			server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Parent comment not found"})
 			return
@@ -78,6 +80,10 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.commentRepo.Create(r.Context(), comment); err != nil {
+		if errors.Is(err, repository.ErrBlocked) {
+			server.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "You can't comment on this post"})
+			return
+		}
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create comment"})
 		return
 	}
@@ -89,14 +95,14 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CommentHandler) GetComment(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/comments/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid comment ID"})
 		return
 	}
 
-	comment, err := h.commentRepo.GetByID(r.Context(), id)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	comment, err := h.commentRepo.GetVisibleByID(r.Context(), id, viewerID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Comment not found"})
 		return
@@ -107,8 +113,7 @@ func (h *CommentHandler) GetComment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/comments/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid comment ID"})
 		return
@@ -131,6 +136,10 @@ func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := authorize(w, r, comment.UserID, models.RoleAdmin); !ok {
+		return
+	}
+
 	comment.Content = req.Content
 	comment.UpdatedAt = time.Now()
 
@@ -144,14 +153,24 @@ func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/comments/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid comment ID"})
 		return
 	}
 
-	if err := h.commentRepo.Delete(r.Context(), id); err != nil {
+	comment, err := h.commentRepo.GetByID(r.Context(), id)
+	if err != nil {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Comment not found"})
+		return
+	}
+
+	userID, ok := authorize(w, r, comment.UserID, models.RoleModerator)
+	if !ok {
+		return
+	}
+
+	if err := h.commentRepo.Delete(r.Context(), id, userID); err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Comment not found"})
 		return
 	}
@@ -160,8 +179,7 @@ func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CommentHandler) ListCommentsByPost(w http.ResponseWriter, r *http.Request) {
-	postIDStr := r.URL.Path[len("/api/posts/") : len("/api/posts/")+36] // UUID length
-	postID, err := uuid.Parse(postIDStr)
+	postID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
@@ -185,7 +203,8 @@ func (h *CommentHandler) ListCommentsByPost(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	comments, err := h.commentRepo.ListByPostID(r.Context(), postID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	comments, err := h.commentRepo.ListByPostID(r.Context(), postID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list comments"})
 		return
@@ -206,8 +225,7 @@ func (h *CommentHandler) ListCommentsByPost(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *CommentHandler) ListCommentsByUser(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Path[len("/api/users/") : len("/api/users/")+36] // UUID length
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -231,7 +249,8 @@ func (h *CommentHandler) ListCommentsByUser(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	comments, err := h.commentRepo.ListByUserID(r.Context(), userID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	comments, err := h.commentRepo.ListByUserID(r.Context(), userID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list user comments"})
 		return
@@ -252,8 +271,7 @@ func (h *CommentHandler) ListCommentsByUser(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *CommentHandler) GetCommentReplies(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/comments/") : len("/api/comments/")+36] // UUID length
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid comment ID"})
 		return
@@ -277,7 +295,8 @@ func (h *CommentHandler) GetCommentReplies(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	comments, err := h.commentRepo.GetReplies(r.Context(), id, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	comments, err := h.commentRepo.GetReplies(r.Context(), id, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get comment replies"})
 		return
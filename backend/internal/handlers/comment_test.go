@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockCommentRepository is a mock implementation of CommentRepository for testing
+type MockCommentRepository struct {
+	comments map[uuid.UUID]*models.Comment
+}
+
+func NewMockCommentRepository() *MockCommentRepository {
+	return &MockCommentRepository{comments: make(map[uuid.UUID]*models.Comment)}
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	m.comments[comment.ID] = comment
+	return nil
+}
+
+func (m *MockCommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
+	comment, exists := m.comments[id]
+	if !exists {
+		return nil, repository.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+func (m *MockCommentRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Comment, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *MockCommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	m.comments[comment.ID] = comment
+	return nil
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
+	delete(m.comments, id)
+	return nil
+}
+
+func (m *MockCommentRepository) ListByPostID(ctx context.Context, postID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	return nil, nil
+}
+
+func (m *MockCommentRepository) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	return nil, nil
+}
+
+func (m *MockCommentRepository) GetReplies(ctx context.Context, parentID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	return nil, nil
+}
+
+func (m *MockCommentRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (int, error) {
+	count := 0
+	for _, comment := range m.comments {
+		if comment.PostID == postID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestCommentHandler_CreateComment_Hidden(t *testing.T) {
+	d := newPostTestData()
+	commentRepo := NewMockCommentRepository()
+	handler := NewCommentHandler(commentRepo, d.posts, NewMockUserRepository(), nil)
+
+	tests := []struct {
+		name       string
+		visibility models.PostVisibility
+		userID     uuid.UUID
+		wantStatus int
+	}{
+		{"followers post by a stranger", models.PostVisibilityFollowers, d.stranger, http.StatusBadRequest},
+		{"only_me post by a stranger", models.PostVisibilityOnlyMe, d.stranger, http.StatusBadRequest},
+		{"only_me post by a follower", models.PostVisibilityOnlyMe, d.follower, http.StatusBadRequest},
+		{"followers post by a follower", models.PostVisibilityFollowers, d.follower, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := d.byVisible[tt.visibility]
+			body := `{"post_id": "` + post.ID.String() + `", "content": "nice"}`
+			req := withUser(httptest.NewRequest("POST", "/api/comments", bytes.NewBufferString(body)), tt.userID)
+			rr := httptest.NewRecorder()
+			handler.CreateComment(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("CreateComment() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+
+			count, _ := commentRepo.CountByPostID(context.Background(), post.ID)
+			if created := count > 0; created != (tt.wantStatus == http.StatusCreated) {
+				t.Errorf("CreateComment() stored a comment = %v, want %v", created, tt.wantStatus == http.StatusCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+)
+
+// archives hold every image a user uploaded, so their downloads aren't held
+// to the server's write timeout but get time for their size at no less than
+// this many bytes a second
+const exportDownloadRate = 64 << 10
+
+type DataExportHandler struct {
+	exportRepo repository.DataExportRepository
+	exportDir  string
+}
+
+func NewDataExportHandler(exportRepo repository.DataExportRepository, exportDir string) *DataExportHandler {
+	return &DataExportHandler{
+		exportRepo: exportRepo,
+		exportDir:  exportDir,
+	}
+}
+
+// RequestExport queues an export of the caller's data. If one is already
+// on its way, that one is returned instead.
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	active, err := h.exportRepo.GetActiveByUserID(r.Context(), userID)
+	if err == nil {
+		server.WriteJSON(w, http.StatusAccepted, active.ToResponse())
+		return
+	}
+	if !errors.Is(err, repository.ErrDataExportNotFound) {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to request export"})
+		return
+	}
+
+	export := &models.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := h.exportRepo.Create(r.Context(), export); err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to request export"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusAccepted, export.ToResponse())
+}
+
+func (h *DataExportHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	exports, err := h.exportRepo.ListByUserID(r.Context(), userID, 20, 0)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list exports"})
+		return
+	}
+
+	responses := make([]models.DataExportResponse, len(exports))
+	for i, export := range exports {
+		responses[i] = export.ToResponse()
+	}
+
+	server.WriteJSON(w, http.StatusOK, responses)
+}
+
+func (h *DataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.ownExport(w, r)
+	if !ok {
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, export.ToResponse())
+}
+
+// DownloadExport serves a finished archive until it expires
+func (h *DataExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.ownExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status == models.DataExportExpired ||
+		(export.Status == models.DataExportReady && export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		server.WriteJSON(w, http.StatusGone, map[string]string{"error": "Export has expired, request a new one"})
+		return
+	}
+	if export.Status != models.DataExportReady {
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Export is not ready yet"})
+		return
+	}
+
+	f, err := os.Open(filepath.Join(h.exportDir, export.FileName()))
+	if err != nil {
+		server.WriteJSON(w, http.StatusGone, map[string]string{"error": "Export has expired, request a new one"})
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to read export"})
+		return
+	}
+
+	// a writer that can't move its deadline keeps the server's
+	deadline := time.Now().Add(time.Minute + time.Duration(info.Size()/exportDownloadRate)*time.Second)
+	http.NewResponseController(w).SetWriteDeadline(deadline)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pin-export-%s.zip"`, export.CreatedAt.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// ownExport loads an export belonging to the caller. Other users' exports
+// are reported as not found.
+func (h *DataExportHandler) ownExport(w http.ResponseWriter, r *http.Request) (*models.DataExport, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid export ID"})
+		return nil, false
+	}
+
+	export, err := h.exportRepo.GetByID(r.Context(), id)
+	if err != nil || export.UserID != userID {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Export not found"})
+		return nil, false
+	}
+
+	return export, true
+}
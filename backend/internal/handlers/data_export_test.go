@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockDataExportRepository is a mock implementation of DataExportRepository for testing
+type MockDataExportRepository struct {
+	exports map[uuid.UUID]*models.DataExport
+}
+
+func NewMockDataExportRepository() *MockDataExportRepository {
+	return &MockDataExportRepository{exports: make(map[uuid.UUID]*models.DataExport)}
+}
+
+func (m *MockDataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	m.exports[export.ID] = export
+	return nil
+}
+
+func (m *MockDataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	export, ok := m.exports[id]
+	if !ok {
+		return nil, repository.ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+func (m *MockDataExportRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.DataExport, error) {
+	var exports []*models.DataExport
+	for _, export := range m.exports {
+		if export.UserID == userID {
+			exports = append(exports, export)
+		}
+	}
+	return exports, nil
+}
+
+func (m *MockDataExportRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	for _, export := range m.exports {
+		if export.UserID == userID && (export.Status == models.DataExportPending || export.Status == models.DataExportRunning) {
+			return export, nil
+		}
+	}
+	return nil, repository.ErrDataExportNotFound
+}
+
+func (m *MockDataExportRepository) ClaimPending(ctx context.Context, staleBefore time.Time) (*models.DataExport, error) {
+	return nil, repository.ErrDataExportNotFound
+}
+
+func (m *MockDataExportRepository) MarkReady(ctx context.Context, id uuid.UUID, sizeBytes int64, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *MockDataExportRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return nil
+}
+
+func (m *MockDataExportRepository) ExpireReady(ctx context.Context, now time.Time) ([]*models.DataExport, error) {
+	return nil, nil
+}
+
+func TestDataExportHandler_RequestExport(t *testing.T) {
+	repo := NewMockDataExportRepository()
+	handler := NewDataExportHandler(repo, t.TempDir())
+	userID := uuid.New()
+
+	request := func() models.DataExportResponse {
+		req := withUser(httptest.NewRequest("POST", "/api/exports", nil), userID)
+		rr := httptest.NewRecorder()
+		handler.RequestExport(rr, req)
+
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("RequestExport() status = %v, want %v", rr.Code, http.StatusAccepted)
+		}
+		var resp models.DataExportResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	first := request()
+	if first.Status != models.DataExportPending || first.DownloadURL != nil {
+		t.Errorf("RequestExport() = %+v, want a pending export without a link", first)
+	}
+
+	// asking again while it's queued doesn't queue another
+	if second := request(); second.ID != first.ID {
+		t.Errorf("RequestExport() queued %v while %v was pending", second.ID, first.ID)
+	}
+}
+
+func TestDataExportHandler_DownloadExport(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewMockDataExportRepository()
+	handler := NewDataExportHandler(repo, dir)
+	userID := uuid.New()
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	ready := &models.DataExport{ID: uuid.New(), UserID: userID, Status: models.DataExportReady, ExpiresAt: &future}
+	stale := &models.DataExport{ID: uuid.New(), UserID: userID, Status: models.DataExportReady, ExpiresAt: &past}
+	pending := &models.DataExport{ID: uuid.New(), UserID: userID, Status: models.DataExportPending}
+	for _, export := range []*models.DataExport{ready, stale, pending} {
+		repo.Create(context.Background(), export)
+		os.WriteFile(filepath.Join(dir, export.FileName()), []byte("PK"), 0o600)
+	}
+
+	tests := []struct {
+		name   string
+		export *models.DataExport
+		caller uuid.UUID
+		want   int
+	}{
+		{"ready", ready, userID, http.StatusOK},
+		{"expired", stale, userID, http.StatusGone},
+		{"not ready", pending, userID, http.StatusConflict},
+		{"someone else's", ready, uuid.New(), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withPathValue(httptest.NewRequest("GET", "/api/exports/"+tt.export.ID.String()+"/download", nil), "id", tt.export.ID.String())
+			req = withUser(req, tt.caller)
+			rr := httptest.NewRecorder()
+			handler.DownloadExport(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("DownloadExport() status = %v, want %v", rr.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rr.Header().Get("Content-Type") != "application/zip" {
+				t.Errorf("DownloadExport() Content-Type = %q", rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
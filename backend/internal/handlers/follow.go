@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -35,10 +36,7 @@ func (h *FollowHandler) FollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	path := r.URL.Path
-	// Extract user ID from /api/users/{id}/follow
-	userIDStr := path[len("/api/users/") : len(path)-len("/follow")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -49,37 +47,51 @@ func (h *FollowHandler) FollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.userRepo.GetByID(r.Context(), userID)
+	user, err := h.userRepo.GetByID(r.Context(), userID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
-	exists, err := h.followRepo.IsFollowing(r.Context(), currentUserID, userID)
-	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check follow status"})
+	existing, err := h.followRepo.GetFollow(r.Context(), currentUserID, userID)
+	switch {
+	case err == nil && existing.Status == models.FollowPending:
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Follow request already sent"})
 		return
-	}
-
-	if exists {
+	case err == nil:
 		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Already following this user"})
 		return
+	case !errors.Is(err, repository.ErrFollowNotFound):
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check follow status"})
+		return
 	}
 
 	follow := &models.Follow{
 		ID:          uuid.New(),
 		FollowerID:  currentUserID,
 		FollowingID: userID,
+		Status:      models.FollowAccepted,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
+	// private accounts approve their followers first
+	status := http.StatusCreated
+	if user.IsPrivate {
+		follow.Status = models.FollowPending
+		status = http.StatusAccepted
+	}
+
 	if err := h.followRepo.CreateFollow(r.Context(), follow); err != nil {
+		if errors.Is(err, repository.ErrBlocked) {
+			server.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "You can't follow this user"})
+			return
+		}
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to follow user"})
 		return
 	}
 
-	server.WriteJSON(w, http.StatusCreated, follow.ToResponse())
+	server.WriteJSON(w, status, follow.ToResponse())
 }
 
 func (h *FollowHandler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
@@ -90,20 +102,18 @@ func (h *FollowHandler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/follow")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
 	}
 
+	// this also withdraws a pending follow request
 	if err := h.followRepo.DeleteFollow(r.Context(), currentUserID, userID); err != nil {
-		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Follow relationship not found"})
-		return
-	}
-
-	if err := h.followRepo.DeleteFollow(r.Context(), currentUserID, userID); err != nil {
+		if errors.Is(err, repository.ErrFollowNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Follow relationship not found"})
+			return
+		}
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to unfollow user"})
 		return
 	}
@@ -112,9 +122,7 @@ func (h *FollowHandler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *FollowHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/following")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -138,7 +146,8 @@ func (h *FollowHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.followRepo.ListFollowing(r.Context(), userID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	users, err := h.followRepo.ListFollowing(r.Context(), userID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list following"})
 		return
@@ -159,9 +168,7 @@ func (h *FollowHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *FollowHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/followers")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -185,7 +192,8 @@ func (h *FollowHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.followRepo.ListFollowers(r.Context(), userID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	users, err := h.followRepo.ListFollowers(r.Context(), userID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list followers"})
 		return
@@ -213,32 +221,135 @@ func (h *FollowHandler) CheckFollowStatus(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/follow-status")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
 	}
 
-	// Check if following
-	isFollowing, err := h.followRepo.IsFollowing(r.Context(), currentUserID, userID)
-	if err != nil {
+	// Check if following or waiting on approval
+	var isFollowing, isRequested bool
+	follow, err := h.followRepo.GetFollow(r.Context(), currentUserID, userID)
+	switch {
+	case err == nil:
+		isFollowing = follow.Status == models.FollowAccepted
+		isRequested = follow.Status == models.FollowPending
+	case !errors.Is(err, repository.ErrFollowNotFound):
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check follow status"})
 		return
 	}
 
 	server.WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"is_following": isFollowing,
+		"is_requested": isRequested,
 		"user_id":      userID,
 		"follower_id":  currentUserID,
 	})
 }
 
+// ListFollowRequests lists the users waiting for the caller to approve them
+func (h *FollowHandler) ListFollowRequests(w http.ResponseWriter, r *http.Request) {
+	currentUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20 // default
+	offset := 0 // default
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	users, err := h.followRepo.ListFollowRequests(r.Context(), currentUserID, limit, offset)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list follow requests"})
+		return
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+	}
+
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"users":  responses,
+		"limit":  limit,
+		"offset": offset,
+		"count":  len(responses),
+	})
+}
+
+// ApproveFollowRequest lets the requester from /api/follow-requests/{id}/approve
+// follow the caller
+func (h *FollowHandler) ApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	currentUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	followerID, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.followRepo.AcceptFollowRequest(r.Context(), followerID, currentUserID); err != nil {
+		if errors.Is(err, repository.ErrFollowNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Follow request not found"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to approve follow request"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// DenyFollowRequest drops the pending request from
+// /api/follow-requests/{id}/deny without telling the requester
+func (h *FollowHandler) DenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	currentUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	followerID, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	// only a pending request can be denied; approved followers are removed
+	// by unfollowing
+	if err := h.followRepo.DeleteFollowRequest(r.Context(), followerID, currentUserID); err != nil {
+		if errors.Is(err, repository.ErrFollowNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Follow request not found"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to deny follow request"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusNoContent, nil)
+}
+
 func (h *FollowHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/stats")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+type followKey struct {
+	followerID, followingID uuid.UUID
+}
+
+// MockFollowRepository is a mock implementation of FollowRepository for testing
+type MockFollowRepository struct {
+	follows map[followKey]*models.Follow
+	blocked map[followKey]bool
+}
+
+func NewMockFollowRepository() *MockFollowRepository {
+	return &MockFollowRepository{
+		follows: make(map[followKey]*models.Follow),
+		blocked: make(map[followKey]bool),
+	}
+}
+
+func (m *MockFollowRepository) CreateFollow(ctx context.Context, follow *models.Follow) error {
+	if m.blocked[followKey{follow.FollowerID, follow.FollowingID}] || m.blocked[followKey{follow.FollowingID, follow.FollowerID}] {
+		return repository.ErrBlocked
+	}
+	m.follows[followKey{follow.FollowerID, follow.FollowingID}] = follow
+	return nil
+}
+
+func (m *MockFollowRepository) DeleteFollow(ctx context.Context, followerID, followingID uuid.UUID) error {
+	key := followKey{followerID, followingID}
+	if _, exists := m.follows[key]; !exists {
+		return repository.ErrFollowNotFound
+	}
+	delete(m.follows, key)
+	return nil
+}
+
+func (m *MockFollowRepository) GetFollow(ctx context.Context, followerID, followingID uuid.UUID) (*models.Follow, error) {
+	follow, exists := m.follows[followKey{followerID, followingID}]
+	if !exists {
+		return nil, repository.ErrFollowNotFound
+	}
+	return follow, nil
+}
+
+func (m *MockFollowRepository) ListFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	return nil, nil
+}
+
+func (m *MockFollowRepository) ListFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	return nil, nil
+}
+
+func (m *MockFollowRepository) ListFollowRequests(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	var users []*models.User
+	for key, follow := range m.follows {
+		if key.followingID == userID && follow.Status == models.FollowPending {
+			users = append(users, &models.User{ID: key.followerID})
+		}
+	}
+	return users, nil
+}
+
+func (m *MockFollowRepository) AcceptFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error {
+	follow, exists := m.follows[followKey{followerID, followingID}]
+	if !exists || follow.Status != models.FollowPending {
+		return repository.ErrFollowNotFound
+	}
+	follow.Status = models.FollowAccepted
+	return nil
+}
+
+func (m *MockFollowRepository) DeleteFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error {
+	key := followKey{followerID, followingID}
+	follow, exists := m.follows[key]
+	if !exists || follow.Status != models.FollowPending {
+		return repository.ErrFollowNotFound
+	}
+	delete(m.follows, key)
+	return nil
+}
+
+func (m *MockFollowRepository) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (m *MockFollowRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (m *MockFollowRepository) IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	follow, exists := m.follows[followKey{followerID, followingID}]
+	return exists && follow.Status == models.FollowAccepted, nil
+}
+
+func (m *MockFollowRepository) GetUserStats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error) {
+	return &models.UserStats{UserID: userID}, nil
+}
+
+func TestFollowHandler_FollowUser(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, userRepo)
+
+	followerID := uuid.New()
+	publicID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: publicID, Email: "public@example.com"})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+publicID.String()+"/follow", nil), "id", publicID.String())
+	req = withUser(req, followerID)
+	rr := httptest.NewRecorder()
+	handler.FollowUser(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("FollowUser() status = %v, want %v", rr.Code, http.StatusCreated)
+	}
+	if ok, _ := followRepo.IsFollowing(context.Background(), followerID, publicID); !ok {
+		t.Errorf("FollowUser() didn't follow a public account straight away")
+	}
+
+	rr = httptest.NewRecorder()
+	handler.FollowUser(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("FollowUser() status = %v, want %v", rr.Code, http.StatusConflict)
+	}
+}
+
+func TestFollowHandler_FollowUser_Private(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, userRepo)
+
+	followerID := uuid.New()
+	privateID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: privateID, Email: "private@example.com", IsPrivate: true})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+privateID.String()+"/follow", nil), "id", privateID.String())
+	req = withUser(req, followerID)
+	rr := httptest.NewRecorder()
+	handler.FollowUser(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("FollowUser() status = %v, want %v", rr.Code, http.StatusAccepted)
+	}
+
+	var resp models.FollowResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Status != models.FollowPending {
+		t.Errorf("FollowUser() status = %q, want %q", resp.Status, models.FollowPending)
+	}
+	if ok, _ := followRepo.IsFollowing(context.Background(), followerID, privateID); ok {
+		t.Errorf("FollowUser() followed a private account without approval")
+	}
+}
+
+func TestFollowHandler_FollowUser_Blocked(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, userRepo)
+
+	followerID := uuid.New()
+	blockerID := uuid.New()
+	userRepo.Create(context.Background(), &models.User{ID: blockerID, Email: "blocker@example.com"})
+	followRepo.blocked[followKey{blockerID, followerID}] = true
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/users/"+blockerID.String()+"/follow", nil), "id", blockerID.String())
+	req = withUser(req, followerID)
+	rr := httptest.NewRecorder()
+	handler.FollowUser(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("FollowUser() status = %v, want %v", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestFollowHandler_ApproveFollowRequest(t *testing.T) {
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, NewMockUserRepository())
+
+	ownerID := uuid.New()
+	requesterID := uuid.New()
+	followRepo.CreateFollow(context.Background(), &models.Follow{ID: uuid.New(), FollowerID: requesterID, FollowingID: ownerID, Status: models.FollowPending})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/follow-requests/"+requesterID.String()+"/approve", nil), "id", requesterID.String())
+	req = withUser(req, ownerID)
+	rr := httptest.NewRecorder()
+	handler.ApproveFollowRequest(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("ApproveFollowRequest() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if ok, _ := followRepo.IsFollowing(context.Background(), requesterID, ownerID); !ok {
+		t.Errorf("ApproveFollowRequest() didn't accept the request")
+	}
+
+	// already approved
+	rr = httptest.NewRecorder()
+	handler.ApproveFollowRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("ApproveFollowRequest() status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestFollowHandler_DenyFollowRequest(t *testing.T) {
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, NewMockUserRepository())
+
+	ownerID := uuid.New()
+	requesterID := uuid.New()
+	followerID := uuid.New()
+	followRepo.CreateFollow(context.Background(), &models.Follow{ID: uuid.New(), FollowerID: requesterID, FollowingID: ownerID, Status: models.FollowPending})
+	followRepo.CreateFollow(context.Background(), &models.Follow{ID: uuid.New(), FollowerID: followerID, FollowingID: ownerID, Status: models.FollowAccepted})
+
+	req := withPathValue(httptest.NewRequest("POST", "/api/follow-requests/"+requesterID.String()+"/deny", nil), "id", requesterID.String())
+	req = withUser(req, ownerID)
+	rr := httptest.NewRecorder()
+	handler.DenyFollowRequest(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("DenyFollowRequest() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if _, err := followRepo.GetFollow(context.Background(), requesterID, ownerID); err == nil {
+		t.Errorf("DenyFollowRequest() left the request in place")
+	}
+
+	// an approved follower isn't a request
+	req = withPathValue(httptest.NewRequest("POST", "/api/follow-requests/"+followerID.String()+"/deny", nil), "id", followerID.String())
+	req = withUser(req, ownerID)
+	rr = httptest.NewRecorder()
+	handler.DenyFollowRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("DenyFollowRequest() status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+	if _, err := followRepo.GetFollow(context.Background(), followerID, ownerID); err != nil {
+		t.Errorf("DenyFollowRequest() removed an approved follower")
+	}
+}
+
+func TestFollowHandler_UnfollowUser(t *testing.T) {
+	followRepo := NewMockFollowRepository()
+	handler := NewFollowHandler(followRepo, NewMockUserRepository())
+
+	followerID := uuid.New()
+	userID := uuid.New()
+	followRepo.CreateFollow(context.Background(), &models.Follow{ID: uuid.New(), FollowerID: followerID, FollowingID: userID, Status: models.FollowAccepted})
+
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+userID.String()+"/follow", nil), "id", userID.String())
+	req = withUser(req, followerID)
+	rr := httptest.NewRecorder()
+	handler.UnfollowUser(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("UnfollowUser() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.UnfollowUser(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("UnfollowUser() status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
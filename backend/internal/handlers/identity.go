@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+	"golang.org/x/oauth2"
+)
+
+// ListIdentities returns the sign in providers linked to the caller
+func (h *OAuthHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	accounts, err := h.oauthRepo.GetAccountsByUserID(r.Context(), userID)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get identities"})
+		return
+	}
+
+	responses := make([]models.OAuthAccountResponse, len(accounts))
+	for i, account := range accounts {
+		responses[i] = account.ToResponse()
+	}
+
+	server.WriteJSON(w, http.StatusOK, responses)
+}
+
+// Link starts linking another provider to the caller: POST
+// /api/auth/{provider}/link. The caller's session travels in a header, so
+// this hands back the provider URL for the client to open rather than
+// redirecting. The callback then attaches the identity instead of signing in.
+func (h *OAuthHandler) Link(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	provider, ok := h.provider(r)
+	if !ok {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Unknown provider"})
+		return
+	}
+
+	redirectURL, ok := h.redirectURLFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.generateState(r.Context(), models.OAuthProvider(provider.Name()), redirectURL, &userID)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate state"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state)
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadGateway, map[string]string{"error": "Provider unavailable"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, map[string]string{"auth_url": authURL})
+}
+
+// UnlinkIdentity removes one of the caller's identities. The last one can't
+// be removed or the account would have no way to sign in.
+func (h *OAuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid identity ID"})
+		return
+	}
+
+	if err := h.oauthRepo.UnlinkAccount(r.Context(), id, userID); err != nil {
+		writeOAuthAccountError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkAccount attaches an identity to userID. Relinking one the user already
+// has just refreshes its tokens.
+func (h *OAuthHandler) linkAccount(ctx context.Context, userID uuid.UUID, provider models.OAuthProvider, userInfo *oauth.UserInfo, token *oauth2.Token) (*models.OAuthAccount, error) {
+	if userInfo.ID == "" {
+		return nil, fmt.Errorf("provider ID not found in user info")
+	}
+
+	existing, err := h.oauthRepo.GetAccountByProvider(ctx, provider, userInfo.ID)
+	switch {
+	case err == nil:
+		if existing.UserID != userID {
+			return nil, errIdentityLinked
+		}
+		if err := h.refreshAccount(ctx, existing, token); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	case !errors.Is(err, repository.ErrOAuthAccountNotFound):
+		return nil, fmt.Errorf("failed to get OAuth account: %w", err)
+	}
+
+	account := newOAuthAccount(userID, provider, userInfo, token)
+	if err := h.oauthRepo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to create OAuth account: %w", err)
+	}
+
+	return account, nil
+}
+
+func writeOAuthAccountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errEmailInUse):
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "An account with this email already exists. Sign in to it and link this provider instead"})
+	case errors.Is(err, errIdentityLinked):
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "This sign in is already linked to another account"})
+	case errors.Is(err, repository.ErrLastOAuthAccount):
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Cannot unlink your only sign in method"})
+	case errors.Is(err, repository.ErrOAuthAccountNotFound):
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Identity not found"})
+	default:
+		slog.Error("failed to link account", "error", err)
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to link account"})
+	}
+}
+
+// writeCallbackError sends the app back to redirectURL with an error code,
+// or writes the error as JSON when there's nowhere to go
+func writeCallbackError(w http.ResponseWriter, r *http.Request, redirectURL string, err error) {
+	if redirectURL == "" {
+		writeOAuthAccountError(w, err)
+		return
+	}
+	redirectWithParams(w, r, redirectURL, url.Values{"error": {oauthErrorCode(err)}})
+}
+
+// oauthErrorCode is the error passed back to the app on a callback redirect
+func oauthErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errEmailInUse):
+		return "email_in_use"
+	case errors.Is(err, errIdentityLinked):
+		return "identity_linked"
+	default:
+		return "server_error"
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
+	"github.com/pin-app/pin/internal/repository"
+	"golang.org/x/oauth2"
+)
+
+// MockOAuthRepository is a mock implementation of OAuthRepository for testing
+type MockOAuthRepository struct {
+	accounts      map[uuid.UUID]*models.OAuthAccount
+	states        map[string]*models.OAuthState
+	exchangeCodes map[string]*models.OAuthExchangeCode
+}
+
+func NewMockOAuthRepository() *MockOAuthRepository {
+	return &MockOAuthRepository{
+		accounts:      make(map[uuid.UUID]*models.OAuthAccount),
+		states:        make(map[string]*models.OAuthState),
+		exchangeCodes: make(map[string]*models.OAuthExchangeCode),
+	}
+}
+
+func (m *MockOAuthRepository) CreateAccount(ctx context.Context, account *models.OAuthAccount) error {
+	m.accounts[account.ID] = account
+	return nil
+}
+
+func (m *MockOAuthRepository) GetAccountByProvider(ctx context.Context, provider models.OAuthProvider, providerID string) (*models.OAuthAccount, error) {
+	for _, a := range m.accounts {
+		if a.Provider == provider && a.ProviderID == providerID {
+			return a, nil
+		}
+	}
+	return nil, repository.ErrOAuthAccountNotFound
+}
+
+func (m *MockOAuthRepository) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OAuthAccount, error) {
+	var accounts []*models.OAuthAccount
+	for _, a := range m.accounts {
+		if a.UserID == userID {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts, nil
+}
+
+func (m *MockOAuthRepository) UpdateAccount(ctx context.Context, account *models.OAuthAccount) error {
+	m.accounts[account.ID] = account
+	return nil
+}
+
+func (m *MockOAuthRepository) DeleteAccount(ctx context.Context, id uuid.UUID) error {
+	delete(m.accounts, id)
+	return nil
+}
+
+func (m *MockOAuthRepository) UnlinkAccount(ctx context.Context, id, userID uuid.UUID) error {
+	account, ok := m.accounts[id]
+	if !ok || account.UserID != userID {
+		return repository.ErrOAuthAccountNotFound
+	}
+	accounts, _ := m.GetAccountsByUserID(ctx, userID)
+	if len(accounts) <= 1 {
+		return repository.ErrLastOAuthAccount
+	}
+	delete(m.accounts, id)
+	return nil
+}
+
+func (m *MockOAuthRepository) ReencryptTokens(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockOAuthRepository) CreateState(ctx context.Context, state *models.OAuthState) error {
+	m.states[state.State] = state
+	return nil
+}
+
+func (m *MockOAuthRepository) GetState(ctx context.Context, state string) (*models.OAuthState, error) {
+	s, ok := m.states[state]
+	if !ok {
+		return nil, repository.ErrOAuthStateNotFound
+	}
+	return s, nil
+}
+
+func (m *MockOAuthRepository) DeleteState(ctx context.Context, state string) error {
+	delete(m.states, state)
+	return nil
+}
+
+func (m *MockOAuthRepository) CreateExchangeCode(ctx context.Context, code *models.OAuthExchangeCode) error {
+	m.exchangeCodes[code.Code] = code
+	return nil
+}
+
+func (m *MockOAuthRepository) RedeemExchangeCode(ctx context.Context, code string) (*models.OAuthExchangeCode, error) {
+	c, ok := m.exchangeCodes[code]
+	if !ok || c.UsedAt != nil || c.ExpiresAt.Before(time.Now()) {
+		return nil, repository.ErrExchangeCodeNotFound
+	}
+	now := time.Now()
+	c.UsedAt = &now
+	return c, nil
+}
+
+func (m *MockOAuthRepository) CleanupExpiredStates(ctx context.Context) error {
+	return nil
+}
+
+func newIdentityTestHandler() (*OAuthHandler, *MockUserRepository, *MockOAuthRepository) {
+	userRepo := NewMockUserRepository()
+	oauthRepo := NewMockOAuthRepository()
+	return NewOAuthHandler(oauthRepo, userRepo, nil, oauth.NewRegistry(), nil), userRepo, oauthRepo
+}
+
+func TestOAuthHandler_UserForNewIdentity(t *testing.T) {
+	ctx := context.Background()
+	h, userRepo, oauthRepo := newIdentityTestHandler()
+
+	// ada signed up with Google, so her email is proven
+	ada := &models.User{ID: uuid.New(), Email: "ada@example.com"}
+	userRepo.users[ada.ID] = ada
+	oauthRepo.accounts[uuid.New()] = &models.OAuthAccount{
+		UserID: ada.ID, Provider: "google", ProviderID: "g-1", ProviderEmail: stringPtr("ada@example.com"), EmailVerified: true,
+	}
+
+	// grace was created through POST /api/users and never proved anything
+	grace := &models.User{ID: uuid.New(), Email: "grace@example.com"}
+	userRepo.users[grace.ID] = grace
+
+	tests := []struct {
+		name     string
+		info     oauth.UserInfo
+		wantUser uuid.UUID
+		wantErr  error
+	}{
+		{name: "verified email merges", info: oauth.UserInfo{ID: "a-1", Email: "ada@example.com", EmailVerified: true}, wantUser: ada.ID},
+		{name: "unverified email refused", info: oauth.UserInfo{ID: "a-2", Email: "ada@example.com"}, wantErr: errEmailInUse},
+		{name: "unproven existing user refused", info: oauth.UserInfo{ID: "a-3", Email: "grace@example.com", EmailVerified: true}, wantErr: errEmailInUse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := h.userForNewIdentity(ctx, &tt.info)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("userForNewIdentity failed: %v", err)
+			}
+			if user.ID != tt.wantUser {
+				t.Errorf("expected user %s, got %s", tt.wantUser, user.ID)
+			}
+		})
+	}
+
+	t.Run("new email creates user", func(t *testing.T) {
+		user, err := h.userForNewIdentity(ctx, &oauth.UserInfo{ID: "n-1", Email: "new@example.com", Name: "New"})
+		if err != nil {
+			t.Fatalf("userForNewIdentity failed: %v", err)
+		}
+		if _, ok := userRepo.users[user.ID]; !ok {
+			t.Error("expected a new user to be created")
+		}
+	})
+}
+
+func TestOAuthHandler_UnverifiedEmailCantClaimAccount(t *testing.T) {
+	ctx := context.Background()
+	h, userRepo, _ := newIdentityTestHandler()
+	token := &oauth2.Token{AccessToken: "at"}
+
+	// an attacker signs up through a provider that doesn't verify emails,
+	// giving the victim's address
+	attacker, err := h.processOAuthAccount(ctx, "sketchy", &oauth.UserInfo{ID: "s-1", Email: "victim@example.com"}, token)
+	if err != nil {
+		t.Fatalf("processOAuthAccount failed: %v", err)
+	}
+	if attacker.Email == "victim@example.com" {
+		t.Fatal("an unverified email was stored as the user's email")
+	}
+	if _, err := userRepo.GetByEmail(ctx, "victim@example.com"); err == nil {
+		t.Fatal("an unverified email can be looked up as a user")
+	}
+
+	// the victim then signs in with a provider that verified the address
+	victim, err := h.processOAuthAccount(ctx, "google", &oauth.UserInfo{ID: "g-1", Email: "victim@example.com", EmailVerified: true}, token)
+	if err != nil {
+		t.Fatalf("processOAuthAccount failed: %v", err)
+	}
+	if victim.ID == attacker.ID {
+		t.Fatal("the victim's sign in was merged into the attacker's account")
+	}
+	if victim.Email != "victim@example.com" {
+		t.Errorf("expected the verified email on the new user, got %q", victim.Email)
+	}
+}
+
+func TestOAuthHandler_LinkAccount(t *testing.T) {
+	ctx := context.Background()
+	h, _, oauthRepo := newIdentityTestHandler()
+
+	owner, other := uuid.New(), uuid.New()
+	taken := &models.OAuthAccount{ID: uuid.New(), UserID: other, Provider: "apple", ProviderID: "taken"}
+	oauthRepo.accounts[taken.ID] = taken
+
+	token := &oauth2.Token{AccessToken: "at"}
+
+	if _, err := h.linkAccount(ctx, owner, "apple", &oauth.UserInfo{ID: "taken"}, token); !errors.Is(err, errIdentityLinked) {
+		t.Fatalf("expected errIdentityLinked, got %v", err)
+	}
+
+	account, err := h.linkAccount(ctx, owner, "apple", &oauth.UserInfo{ID: "fresh"}, token)
+	if err != nil {
+		t.Fatalf("linkAccount failed: %v", err)
+	}
+	if account.UserID != owner {
+		t.Errorf("expected identity linked to %s, got %s", owner, account.UserID)
+	}
+}
+
+func TestOAuthHandler_UnlinkIdentity(t *testing.T) {
+	h, _, oauthRepo := newIdentityTestHandler()
+
+	userID := uuid.New()
+	google := &models.OAuthAccount{ID: uuid.New(), UserID: userID, Provider: "google", ProviderID: "g"}
+	apple := &models.OAuthAccount{ID: uuid.New(), UserID: userID, Provider: "apple", ProviderID: "a"}
+	oauthRepo.accounts[google.ID] = google
+	oauthRepo.accounts[apple.ID] = apple
+
+	unlink := func(id uuid.UUID) int {
+		req := withUser(withPathValue(httptest.NewRequest("DELETE", "/api/identities/"+id.String(), nil), "id", id.String()), userID)
+		rr := httptest.NewRecorder()
+		h.UnlinkIdentity(rr, req)
+		return rr.Code
+	}
+
+	if code := unlink(google.ID); code != http.StatusNoContent {
+		t.Fatalf("expected 204 unlinking one of two identities, got %d", code)
+	}
+	if code := unlink(apple.ID); code != http.StatusConflict {
+		t.Errorf("expected 409 unlinking the last identity, got %d", code)
+	}
+	if code := unlink(uuid.New()); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown identity, got %d", code)
+	}
+}
+
+func TestWriteOAuthAccountError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"email in use", fmt.Errorf("sign in: %w", errEmailInUse), http.StatusConflict, "An account with this email already exists"},
+		{"identity linked", errIdentityLinked, http.StatusConflict, "already linked to another account"},
+		{"internal error", fmt.Errorf("failed to create OAuth account: %w", errors.New(`pq: duplicate key value violates unique constraint "oauth_accounts_pkey"`)), http.StatusInternalServerError, "Failed to link account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writeOAuthAccountError(rr, tt.err)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if body := rr.Body.String(); !strings.Contains(body, tt.wantBody) || strings.Contains(body, "pq:") {
+				t.Errorf("body = %s, want %q and no internal error", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/mail"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+)
+
+const (
+	magicLinkTTL = 15 * time.Minute
+
+	// at most this many links per address, and per client IP, per window
+	magicLinkWindow      = time.Hour
+	magicLinksPerEmail   = 5
+	magicLinksPerAddress = 20
+)
+
+type MagicLinkHandler struct {
+	magicLinkRepo repository.MagicLinkRepository
+	userRepo      repository.UserRepository
+	authMW        *middleware.AuthMiddleware
+	mailer        mail.Mailer
+	linkURL       string
+	validator     *validator.Validate
+}
+
+// NewMagicLinkHandler mails links to linkURL with the token added as the
+// token query parameter. linkURL is usually an app deep link that posts the
+// token back to Redeem.
+func NewMagicLinkHandler(magicLinkRepo repository.MagicLinkRepository, userRepo repository.UserRepository, authMW *middleware.AuthMiddleware, mailer mail.Mailer, linkURL string) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkRepo: magicLinkRepo,
+		userRepo:      userRepo,
+		authMW:        authMW,
+		mailer:        mailer,
+		linkURL:       linkURL,
+		validator:     validator.New(),
+	}
+}
+
+// Request mails a one-time sign in link. It answers the same whether or not
+// the address has an account, so it can't be used to find out who does.
+func (h *MagicLinkHandler) Request(w http.ResponseWriter, r *http.Request) {
+	var req models.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+		return
+	}
+	// users.email is matched exactly, so every link is for the lowercased
+	// address
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := h.validator.Struct(req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	byEmail, byIP, err := h.magicLinkRepo.CountSince(r.Context(), req.Email, ip, time.Now().Add(-magicLinkWindow))
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to send sign in link"})
+		return
+	}
+	if byEmail >= magicLinksPerEmail || byIP >= magicLinksPerAddress {
+		slog.Warn("magic link rate limit hit", "ip", ip, "by_email", byEmail, "by_ip", byIP)
+		w.Header().Set("Retry-After", fmt.Sprint(int(magicLinkWindow.Seconds())))
+		server.WriteJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Too many sign in links requested, try again later"})
+		return
+	}
+
+	if err := h.sendLink(r.Context(), req.Email, ip); err != nil {
+		slog.Error("failed to send magic link", "error", err)
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to send sign in link"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusAccepted, map[string]string{"message": "If the address can sign in, a link is on its way"})
+}
+
+// Redeem trades the token from a sign in link for a session, creating the
+// user the first time an address signs in
+func (h *MagicLinkHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	var req models.MagicLinkRedeemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "token is required"})
+		return
+	}
+
+	link, err := h.magicLinkRepo.Redeem(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrMagicLinkNotFound) {
+			server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired sign in link"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to sign in"})
+		return
+	}
+
+	user, err := h.userForEmail(r.Context(), link.Email)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to sign in"})
+		return
+	}
+
+	session, err := h.authMW.CreateSession(r.Context(), user.ID, middleware.ClientInfoFromRequest(r))
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create session"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, newOAuthResponse(session, user))
+}
+
+func (h *MagicLinkHandler) sendLink(ctx context.Context, email, ip string) error {
+	token, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	link := &models.MagicLink{
+		ID:        uuid.New(),
+		Email:     email,
+		Token:     token,
+		IPAddress: &ip,
+		ExpiresAt: now.Add(magicLinkTTL),
+		CreatedAt: now,
+	}
+	if err := h.magicLinkRepo.Create(ctx, link); err != nil {
+		return err
+	}
+
+	signInURL, err := url.Parse(h.linkURL)
+	if err != nil {
+		return fmt.Errorf("invalid magic link URL: %w", err)
+	}
+	query := signInURL.Query()
+	query.Set("token", token)
+	signInURL.RawQuery = query.Encode()
+
+	return h.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Your Pin sign in link",
+		Body: fmt.Sprintf("Tap the link below to sign in to Pin. It works once and expires in %d minutes.\n\n%s\n\n"+
+			"If you didn't ask for this, you can ignore this email.\n", int(magicLinkTTL.Minutes()), signInURL),
+	})
+}
+
+// userForEmail finds the user the address belongs to. Redeeming a link
+// proves the caller reads that inbox, which is as strong as any provider's
+// verified email, so a new user is created when there isn't one.
+func (h *MagicLinkHandler) userForEmail(ctx context.Context, email string) (*models.User, error) {
+	email = strings.ToLower(email)
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	user = &models.User{
+		ID:        uuid.New(),
+		Email:     email,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/mail"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
+	"github.com/pin-app/pin/internal/repository"
+	"golang.org/x/oauth2"
+)
+
+// MockMagicLinkRepository is a mock implementation of MagicLinkRepository for testing
+type MockMagicLinkRepository struct {
+	links []*models.MagicLink
+}
+
+func (m *MockMagicLinkRepository) Create(ctx context.Context, link *models.MagicLink) error {
+	m.links = append(m.links, link)
+	return nil
+}
+
+func (m *MockMagicLinkRepository) Redeem(ctx context.Context, token string) (*models.MagicLink, error) {
+	for _, link := range m.links {
+		if link.Token == token && link.UsedAt == nil && link.ExpiresAt.After(time.Now()) {
+			now := time.Now()
+			link.UsedAt = &now
+			return link, nil
+		}
+	}
+	return nil, repository.ErrMagicLinkNotFound
+}
+
+func (m *MockMagicLinkRepository) CountSince(ctx context.Context, email, ipAddress string, since time.Time) (int, int, error) {
+	byEmail, byIP := 0, 0
+	for _, link := range m.links {
+		if link.CreatedAt.Before(since) {
+			continue
+		}
+		if strings.EqualFold(link.Email, email) {
+			byEmail++
+		}
+		if link.IPAddress != nil && *link.IPAddress == ipAddress {
+			byIP++
+		}
+	}
+	return byEmail, byIP, nil
+}
+
+func (m *MockMagicLinkRepository) CleanupExpired(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func requestMagicLink(h *MagicLinkHandler, email string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/auth/magic-link", bytes.NewBufferString(`{"email": "`+email+`"}`))
+	w := httptest.NewRecorder()
+	h.Request(w, req)
+	return w
+}
+
+func redeemMagicLink(h *MagicLinkHandler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/auth/magic-link/redeem", bytes.NewBufferString(`{"token": "`+token+`"}`))
+	w := httptest.NewRecorder()
+	h.Redeem(w, req)
+	return w
+}
+
+// magicLinkToken pulls the token out of the link in a sign in mail
+func magicLinkToken(t *testing.T, message mail.Message) string {
+	t.Helper()
+	for _, field := range strings.Fields(message.Body) {
+		if u, err := url.Parse(field); err == nil && u.Scheme == "pin" {
+			if token := u.Query().Get("token"); token != "" {
+				return token
+			}
+		}
+	}
+	t.Fatalf("no sign in link in mail body: %q", message.Body)
+	return ""
+}
+
+func redeemedUser(t *testing.T, w *httptest.ResponseRecorder) models.UserResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp OAuthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp.User
+}
+
+func TestMagicLinkHandler_SignIn(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	mailer := mail.NewMemoryMailer()
+	authMW := middleware.NewAuthMiddleware(NewMockSessionRepository(), userRepo, nil, middleware.DevMode{})
+	handler := NewMagicLinkHandler(&MockMagicLinkRepository{}, userRepo, authMW, mailer, "pin://auth/magic-link")
+
+	if w := requestMagicLink(handler, "new@example.com"); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].To != "new@example.com" {
+		t.Fatalf("expected one mail to new@example.com, got %+v", messages)
+	}
+
+	token := magicLinkToken(t, messages[0])
+
+	w := redeemMagicLink(handler, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp OAuthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SessionToken == "" || resp.User.Email != "new@example.com" {
+		t.Errorf("expected a session for new@example.com, got %+v", resp)
+	}
+
+	if w := redeemMagicLink(handler, token); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a used link to be refused, got %d", w.Code)
+	}
+}
+
+func TestMagicLinkHandler_RateLimit(t *testing.T) {
+	mailer := mail.NewMemoryMailer()
+	handler := NewMagicLinkHandler(&MockMagicLinkRepository{}, NewMockUserRepository(), nil, mailer, "pin://auth/magic-link")
+
+	for i := 0; i < magicLinksPerEmail; i++ {
+		if w := requestMagicLink(handler, "someone@example.com"); w.Code != http.StatusAccepted {
+			t.Fatalf("request %d: expected status %d, got %d", i, http.StatusAccepted, w.Code)
+		}
+	}
+
+	w := requestMagicLink(handler, "Someone@Example.com")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+	if len(mailer.Messages()) != magicLinksPerEmail {
+		t.Errorf("expected %d mails, got %d", magicLinksPerEmail, len(mailer.Messages()))
+	}
+}
+
+func TestMagicLinkHandler_NormalizesEmail(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	existing := &models.User{ID: uuid.New(), Email: "someone@example.com"}
+	userRepo.Create(context.Background(), existing)
+
+	mailer := mail.NewMemoryMailer()
+	authMW := middleware.NewAuthMiddleware(NewMockSessionRepository(), userRepo, nil, middleware.DevMode{})
+	linkRepo := &MockMagicLinkRepository{}
+	handler := NewMagicLinkHandler(linkRepo, userRepo, authMW, mailer, "pin://auth/magic-link")
+
+	if w := requestMagicLink(handler, " SomeOne@Example.COM "); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if linkRepo.links[0].Email != "someone@example.com" {
+		t.Errorf("expected the link stored for someone@example.com, got %q", linkRepo.links[0].Email)
+	}
+
+	user := redeemedUser(t, redeemMagicLink(handler, magicLinkToken(t, mailer.Messages()[0])))
+	if user.ID != existing.ID {
+		t.Errorf("expected to sign in as the existing user %s, got %s", existing.ID, user.ID)
+	}
+}
+
+func TestMagicLinkHandler_CantReachUnverifiedOAuthUser(t *testing.T) {
+	ctx := context.Background()
+	oauthHandler, userRepo, _ := newIdentityTestHandler()
+
+	// an attacker signs up through a provider that doesn't verify emails,
+	// giving the victim's address
+	attacker, err := oauthHandler.processOAuthAccount(ctx, "sketchy", &oauth.UserInfo{ID: "s-1", Email: "victim@example.com"}, &oauth2.Token{AccessToken: "at"})
+	if err != nil {
+		t.Fatalf("processOAuthAccount failed: %v", err)
+	}
+
+	// the victim then signs in by email
+	mailer := mail.NewMemoryMailer()
+	authMW := middleware.NewAuthMiddleware(NewMockSessionRepository(), userRepo, nil, middleware.DevMode{})
+	handler := NewMagicLinkHandler(&MockMagicLinkRepository{}, userRepo, authMW, mailer, "pin://auth/magic-link")
+	if w := requestMagicLink(handler, "Victim@example.com"); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	user := redeemedUser(t, redeemMagicLink(handler, magicLinkToken(t, mailer.Messages()[0])))
+	if user.ID == attacker.ID {
+		t.Fatal("the magic link signed into the account created from an unverified email")
+	}
+	if user.Email != "victim@example.com" {
+		t.Errorf("expected a new user for victim@example.com, got %q", user.Email)
+	}
+}
@@ -5,93 +5,123 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
 	"github.com/pin-app/pin/internal/repository"
 	"github.com/pin-app/pin/internal/server"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 type OAuthHandler struct {
-	oauthRepo    repository.OAuthRepository
-	userRepo     repository.UserRepository
-	sessionRepo  repository.SessionRepository
-	authMW       *middleware.AuthMiddleware
-	googleConfig *oauth2.Config
-	appleConfig  *oauth2.Config
+	oauthRepo   repository.OAuthRepository
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	authMW      *middleware.AuthMiddleware
+	providers   *oauth.Registry
+	redirects   oauth.RedirectAllowlist
 }
 
+// exchange codes only have to survive the hop from the browser to the app
+const exchangeCodeTTL = time.Minute
+
+var (
+	errEmailInUse     = errors.New("email belongs to another account")
+	errIdentityLinked = errors.New("identity linked to another account")
+)
+
 type OAuthCallbackRequest struct {
 	Code  string `json:"code"`
 	State string `json:"state"`
 }
 
+// OAuthResponse is a new token pair. ExpiresAt is when the session token
+// stops working; the refresh token is good until RefreshExpiresAt.
 type OAuthResponse struct {
-	SessionToken string              `json:"session_token"`
-	User         models.UserResponse `json:"user"`
-	ExpiresAt    time.Time           `json:"expires_at"`
+	SessionToken     string              `json:"session_token"`
+	RefreshToken     string              `json:"refresh_token"`
+	User             models.UserResponse `json:"user"`
+	ExpiresAt        time.Time           `json:"expires_at"`
+	RefreshExpiresAt time.Time           `json:"refresh_expires_at"`
 }
 
-func NewOAuthHandler(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, sessionRepo repository.SessionRepository) *OAuthHandler {
-	authMW := middleware.NewAuthMiddleware(sessionRepo, userRepo)
-
-	// Google OAuth configuration
-	googleConfig := &oauth2.Config{
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",
-			"https://www.googleapis.com/auth/userinfo.profile",
-		},
-		Endpoint: google.Endpoint,
-	}
-
-	// Apple OAuth configuration
-	appleConfig := &oauth2.Config{
-		ClientID:     os.Getenv("APPLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("APPLE_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("APPLE_REDIRECT_URL"),
-		Scopes:       []string{"name", "email"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://appleid.apple.com/auth/authorize",
-			TokenURL: "https://appleid.apple.com/auth/token",
-		},
-	}
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// NewOAuthHandler signs users in with providers. The callback only ever hands
+// off to app URLs in redirects.
+func NewOAuthHandler(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, providers *oauth.Registry, redirects oauth.RedirectAllowlist) *OAuthHandler {
+	authMW := middleware.NewAuthMiddleware(sessionRepo, userRepo, nil, middleware.DevMode{})
 
 	return &OAuthHandler{
-		oauthRepo:    oauthRepo,
-		userRepo:     userRepo,
-		sessionRepo:  sessionRepo,
-		authMW:       authMW,
-		googleConfig: googleConfig,
-		appleConfig:  appleConfig,
+		oauthRepo:   oauthRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		authMW:      authMW,
+		providers:   providers,
+		redirects:   redirects,
 	}
 }
 
-// Google OAuth
+// ListProviders returns the names of the configured sign in providers
+func (h *OAuthHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	server.WriteJSON(w, http.StatusOK, map[string][]string{"providers": h.providers.Names()})
+}
 
-func (h *OAuthHandler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
-	state, err := h.generateState(models.OAuthProviderGoogle, r.URL.Query().Get("redirect_url"))
+// Auth redirects to the provider named in the path: /api/auth/{provider}
+func (h *OAuthHandler) Auth(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.provider(r)
+	if !ok {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Unknown provider"})
+		return
+	}
+
+	redirectURL, ok := h.redirectURLFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.generateState(r.Context(), models.OAuthProvider(provider.Name()), redirectURL, nil)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate state"})
 		return
 	}
 
-	authURL := h.googleConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	authURL, err := provider.AuthCodeURL(r.Context(), state)
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadGateway, map[string]string{"error": "Provider unavailable"})
+		return
+	}
+
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-func (h *OAuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
-	state := r.URL.Query().Get("state")
+// Callback finishes sign in: /api/auth/{provider}/callback. Providers that
+// use form_post (Apple) call it with POST, so both methods are routed here.
+//
+// It never returns tokens, since whatever opened it is usually a browser.
+// Instead it issues a one-time code, redirecting to the app's redirect_url
+// with ?code=... when there is one, for the app to trade at Exchange.
+// Failures after the state checks out redirect with ?error=... instead.
+func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.provider(r)
+	if !ok {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Unknown provider"})
+		return
+	}
+
+	// FormValue covers both the query string and a form_post body
+	code := r.FormValue("code")
+	state := r.FormValue("state")
 
 	if code == "" || state == "" {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Missing code or state parameter"})
@@ -100,100 +130,139 @@ func (h *OAuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 
 	// Verify state
 	oauthState, err := h.oauthRepo.GetState(r.Context(), state)
-	if err != nil {
+	if err != nil || string(oauthState.Provider) != provider.Name() {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid or expired state"})
 		return
 	}
 
+	// the state is single use whatever happens next
+	_ = h.oauthRepo.DeleteState(r.Context(), state)
+
+	// checked again in case the allow list changed since the state was made
+	redirectURL := ""
+	if oauthState.RedirectURL != nil && h.redirects.Allowed(*oauthState.RedirectURL) {
+		redirectURL = *oauthState.RedirectURL
+	}
+
 	// Exchange code for token
-	token, err := h.googleConfig.Exchange(r.Context(), code)
+	token, err := provider.Exchange(r.Context(), code, oauthState)
 	if err != nil {
+		if redirectURL != "" {
+			redirectWithParams(w, r, redirectURL, url.Values{"error": {"exchange_failed"}})
+			return
+		}
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to exchange code for token"})
 		return
 	}
 
-	// Get user info from Google
-	userInfo, err := h.getGoogleUserInfo(r.Context(), token.AccessToken)
+	// Get user info, verifying the id_token and nonce where there is one
+	userInfo, err := provider.UserInfo(r.Context(), token, oauthState, r.Form)
 	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get user info"})
+		if redirectURL != "" {
+			redirectWithParams(w, r, redirectURL, url.Values{"error": {"verification_failed"}})
+			return
+		}
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Failed to verify user info"})
 		return
 	}
 
-	// Process OAuth account
-	redirectURL := ""
-	if oauthState.RedirectURL != nil {
-		redirectURL = *oauthState.RedirectURL
+	// A state created by Link attaches the identity to that user instead of
+	// signing in
+	if oauthState.UserID != nil {
+		account, err := h.linkAccount(r.Context(), *oauthState.UserID, oauthState.Provider, userInfo, token)
+		if err != nil {
+			writeCallbackError(w, r, redirectURL, err)
+			return
+		}
+		if redirectURL != "" {
+			redirectWithParams(w, r, redirectURL, url.Values{"linked": {provider.Name()}})
+			return
+		}
+		server.WriteJSON(w, http.StatusOK, account.ToResponse())
+		return
 	}
-	response, err := h.processOAuthAccount(r.Context(), models.OAuthProviderGoogle, userInfo, token, redirectURL)
+
+	user, err := h.processOAuthAccount(r.Context(), oauthState.Provider, userInfo, token)
 	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writeCallbackError(w, r, redirectURL, err)
 		return
 	}
 
-	// Clean up state
-	_ = h.oauthRepo.DeleteState(r.Context(), state)
-
-	server.WriteJSON(w, http.StatusOK, response)
-}
-
-// Apple OAuth
-
-func (h *OAuthHandler) AppleAuth(w http.ResponseWriter, r *http.Request) {
-	state, err := h.generateState(models.OAuthProviderApple, r.URL.Query().Get("redirect_url"))
+	exchangeCode, err := h.createExchangeCode(r.Context(), user.ID)
 	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate state"})
+		writeCallbackError(w, r, redirectURL, err)
 		return
 	}
 
-	authURL := h.appleConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
-	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+	if redirectURL != "" {
+		redirectWithParams(w, r, redirectURL, url.Values{"code": {exchangeCode.Code}})
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]any{"code": exchangeCode.Code, "expires_at": exchangeCode.ExpiresAt})
 }
 
-func (h *OAuthHandler) AppleCallback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
-	state := r.URL.Query().Get("state")
-
-	if code == "" || state == "" {
-		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Missing code or state parameter"})
+// Exchange trades the one-time code from Callback for a session
+func (h *OAuthHandler) Exchange(w http.ResponseWriter, r *http.Request) {
+	var req models.OAuthExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "code is required"})
 		return
 	}
 
-	// Verify state
-	oauthState, err := h.oauthRepo.GetState(r.Context(), state)
+	exchangeCode, err := h.oauthRepo.RedeemExchangeCode(r.Context(), req.Code)
 	if err != nil {
-		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid or expired state"})
+		if errors.Is(err, repository.ErrExchangeCodeNotFound) {
+			server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired code"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to exchange code"})
 		return
 	}
 
-	// Exchange code for token
-	token, err := h.appleConfig.Exchange(r.Context(), code)
+	user, err := h.userRepo.GetByID(r.Context(), exchangeCode.UserID)
 	if err != nil {
-		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to exchange code for token"})
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired code"})
 		return
 	}
 
-	// Get user info from Apple
-	userInfo, err := h.getAppleUserInfo(r.Context(), token.AccessToken)
+	session, err := h.authMW.CreateSession(r.Context(), user.ID, middleware.ClientInfoFromRequest(r))
 	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get user info"})
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create session"})
 		return
 	}
 
-	// Process OAuth account
-	redirectURL := ""
-	if oauthState.RedirectURL != nil {
-		redirectURL = *oauthState.RedirectURL
+	server.WriteJSON(w, http.StatusOK, newOAuthResponse(session, user))
+}
+
+// Refresh trades a refresh token for a new token pair. Each refresh token
+// works once; replaying one signs the whole session out.
+func (h *OAuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "refresh_token is required"})
+		return
 	}
-	response, err := h.processOAuthAccount(r.Context(), models.OAuthProviderApple, userInfo, token, redirectURL)
+
+	session, err := h.authMW.RefreshSession(r.Context(), req.RefreshToken)
 	if err != nil {
-		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		switch {
+		case errors.Is(err, repository.ErrRefreshTokenReused):
+			server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Refresh token reuse detected, session revoked"})
+		case errors.Is(err, repository.ErrSessionNotFound):
+			server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired refresh token"})
+		default:
+			server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to refresh session"})
+		}
 		return
 	}
 
-	// Clean up state
-	_ = h.oauthRepo.DeleteState(r.Context(), state)
+	user, err := h.userRepo.GetByID(r.Context(), session.UserID)
+	if err != nil {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired refresh token"})
+		return
+	}
 
-	server.WriteJSON(w, http.StatusOK, response)
+	server.WriteJSON(w, http.StatusOK, newOAuthResponse(session, user))
 }
 
 // Logout
@@ -228,159 +297,256 @@ func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 // Helper methods
 
-func (h *OAuthHandler) generateState(provider models.OAuthProvider, redirectURL string) (string, error) {
-	stateBytes := make([]byte, 32)
-	if _, err := rand.Read(stateBytes); err != nil {
-		return "", err
+// provider looks up the {provider} path parameter
+func (h *OAuthHandler) provider(r *http.Request) (oauth.Provider, bool) {
+	return h.providers.Get(r.PathValue("provider"))
+}
+
+// generateState stores a fresh state along with the PKCE verifier and OIDC
+// nonce that the callback will need
+func (h *OAuthHandler) generateState(ctx context.Context, provider models.OAuthProvider, redirectURL string, linkUserID *uuid.UUID) (*models.OAuthState, error) {
+	state, err := randomHex(32)
+	if err != nil {
+		return nil, err
 	}
-	state := hex.EncodeToString(stateBytes)
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
 
 	oauthState := &models.OAuthState{
-		ID:          uuid.New(),
-		State:       state,
-		Provider:    provider,
-		RedirectURL: &redirectURL,
-		ExpiresAt:   time.Now().Add(10 * time.Minute),
-		CreatedAt:   time.Now(),
+		ID:           uuid.New(),
+		State:        state,
+		CodeVerifier: &verifier,
+		Nonce:        &nonce,
+		Provider:     provider,
+		RedirectURL:  &redirectURL,
+		UserID:       linkUserID,
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+		CreatedAt:    time.Now(),
+	}
+
+	if err := h.oauthRepo.CreateState(ctx, oauthState); err != nil {
+		return nil, err
 	}
 
-	if err := h.oauthRepo.CreateState(context.Background(), oauthState); err != nil {
-		return "", err
-	}
+	return oauthState, nil
+}
 
-	return state, nil
+// redirectURLFromRequest reads the optional redirect_url query parameter,
+// writing a 400 if it isn't on the allow list
+func (h *OAuthHandler) redirectURLFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	redirectURL := r.URL.Query().Get("redirect_url")
+	if redirectURL != "" && !h.redirects.Allowed(redirectURL) {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "redirect_url is not allowed"})
+		return "", false
+	}
+	return redirectURL, true
 }
 
-func (h *OAuthHandler) getGoogleUserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
-	client := h.googleConfig.Client(ctx, &oauth2.Token{AccessToken: accessToken})
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+func (h *OAuthHandler) createExchangeCode(ctx context.Context, userID uuid.UUID) (*models.OAuthExchangeCode, error) {
+	code, err := randomHex(32)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var userInfo map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+	now := time.Now()
+	exchangeCode := &models.OAuthExchangeCode{
+		ID:        uuid.New(),
+		Code:      code,
+		UserID:    userID,
+		ExpiresAt: now.Add(exchangeCodeTTL),
+		CreatedAt: now,
+	}
+	if err := h.oauthRepo.CreateExchangeCode(ctx, exchangeCode); err != nil {
 		return nil, err
 	}
 
-	return userInfo, nil
+	return exchangeCode, nil
 }
 
-func (h *OAuthHandler) getAppleUserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
-	// Apple doesn't provide a user info endpoint, so we need to decode the ID token
-	// For simplicity, we'll create a basic user info structure
-	// In a real implementation, you'd decode the JWT ID token
-	return map[string]interface{}{
-		"id":    "apple_user_" + accessToken[:8], // Simplified
-		"email": "user@privaterelay.appleid.com", // Apple uses private relay
-		"name":  "Apple User",
-	}, nil
+// redirectWithParams adds params to an already validated redirect URL
+func redirectWithParams(w http.ResponseWriter, r *http.Request, redirectURL string, params url.Values) {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Invalid redirect URL"})
+		return
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u.String(), http.StatusFound)
 }
 
-func (h *OAuthHandler) processOAuthAccount(ctx context.Context, provider models.OAuthProvider, userInfo map[string]interface{}, token *oauth2.Token, redirectURL string) (*OAuthResponse, error) {
-	providerID := h.getStringFromMap(userInfo, "id")
-	email := h.getStringFromMap(userInfo, "email")
-	name := h.getStringFromMap(userInfo, "name")
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-	if providerID == "" {
+// processOAuthAccount finds or creates the user an identity signs in as
+func (h *OAuthHandler) processOAuthAccount(ctx context.Context, provider models.OAuthProvider, userInfo *oauth.UserInfo, token *oauth2.Token) (*models.User, error) {
+	if userInfo.ID == "" {
 		return nil, fmt.Errorf("provider ID not found in user info")
 	}
 
-	// Check if OAuth account already exists
-	existingAccount, err := h.oauthRepo.GetAccountByProvider(ctx, provider, providerID)
-	if err == nil {
-		// Update existing account
-		existingAccount.AccessToken = &token.AccessToken
-		if token.RefreshToken != "" {
-			existingAccount.RefreshToken = &token.RefreshToken
-		}
-		if !token.Expiry.IsZero() {
-			existingAccount.TokenExpiresAt = &token.Expiry
-		}
-		existingAccount.UpdatedAt = time.Now()
+	var user *models.User
 
-		if err := h.oauthRepo.UpdateAccount(ctx, existingAccount); err != nil {
-			return nil, fmt.Errorf("failed to update OAuth account: %w", err)
+	// Check if OAuth account already exists
+	existingAccount, err := h.oauthRepo.GetAccountByProvider(ctx, provider, userInfo.ID)
+	switch {
+	case err == nil:
+		if err := h.refreshAccount(ctx, existingAccount, token); err != nil {
+			return nil, err
 		}
 
-		// Get user
-		user, err := h.userRepo.GetByID(ctx, existingAccount.UserID)
+		user, err = h.userRepo.GetByID(ctx, existingAccount.UserID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get user: %w", err)
 		}
-
-		// Create session
-		session, err := h.authMW.CreateSession(ctx, user.ID)
+	case errors.Is(err, repository.ErrOAuthAccountNotFound):
+		user, err = h.userForNewIdentity(ctx, userInfo)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create session: %w", err)
+			return nil, err
 		}
 
-		return &OAuthResponse{
-			SessionToken: session.SessionToken,
-			User:         user.ToResponse(),
-			ExpiresAt:    session.ExpiresAt,
-		}, nil
+		if err := h.oauthRepo.CreateAccount(ctx, newOAuthAccount(user.ID, provider, userInfo, token)); err != nil {
+			return nil, fmt.Errorf("failed to create OAuth account: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to get OAuth account: %w", err)
 	}
 
-	// Create new user and OAuth account
+	return user, nil
+}
+
+// userForNewIdentity picks the user a first-time identity signs in as. It
+// only merges into an existing user when the provider vouches for the email
+// and that user has already proven the same address through a linked
+// provider. Anything weaker would let someone claim an account just by
+// registering its email somewhere that doesn't verify it.
+func (h *OAuthHandler) userForNewIdentity(ctx context.Context, userInfo *oauth.UserInfo) (*models.User, error) {
+	if userInfo.Email != "" {
+		existing, err := h.userRepo.GetByEmail(ctx, userInfo.Email)
+		switch {
+		case err == nil:
+			if !userInfo.EmailVerified {
+				return nil, errEmailInUse
+			}
+			proven, err := h.hasIdentityWithEmail(ctx, existing.ID, userInfo.Email)
+			if err != nil {
+				return nil, err
+			}
+			if !proven {
+				return nil, errEmailInUse
+			}
+			return existing, nil
+		case !errors.Is(err, repository.ErrUserNotFound):
+			return nil, fmt.Errorf("failed to get user by email: %w", err)
+		}
+	}
+
+	// Create new user. An address the provider didn't verify isn't stored
+	// as the user's email, or it would later pass for the owner's in a
+	// merge or an email sign in.
 	user := &models.User{
 		ID:        uuid.New(),
-		Email:     email,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	user.Email = placeholderEmail(user.ID)
+	if userInfo.Email != "" && userInfo.EmailVerified {
+		user.Email = userInfo.Email
+	}
 
-	if name != "" {
-		user.DisplayName = &name
+	if userInfo.Name != "" {
+		user.DisplayName = &userInfo.Name
 	}
 
 	if err := h.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Create OAuth account
-	oauthAccount := &models.OAuthAccount{
-		ID:            uuid.New(),
-		UserID:        user.ID,
-		Provider:      provider,
-		ProviderID:    providerID,
-		ProviderEmail: &email,
-		ProviderName:  &name,
-		AccessToken:   &token.AccessToken,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+	return user, nil
+}
+
+// hasIdentityWithEmail reports whether one of the user's linked identities
+// had its provider verify email
+func (h *OAuthHandler) hasIdentityWithEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	accounts, err := h.oauthRepo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, account := range accounts {
+		if account.EmailVerified && account.ProviderEmail != nil && strings.EqualFold(*account.ProviderEmail, email) {
+			return true, nil
+		}
 	}
+	return false, nil
+}
+
+// placeholderEmail stands in for the email of a user whose provider gave no
+// verified address. users.email must be unique and set, and .invalid is
+// reserved so it can never reach anyone's inbox or match a real address.
+func placeholderEmail(userID uuid.UUID) string {
+	return userID.String() + "@unverified.invalid"
+}
 
+// refreshAccount stores the tokens from a fresh sign in
+func (h *OAuthHandler) refreshAccount(ctx context.Context, account *models.OAuthAccount, token *oauth2.Token) error {
+	account.AccessToken = &token.AccessToken
 	if token.RefreshToken != "" {
-		oauthAccount.RefreshToken = &token.RefreshToken
+		account.RefreshToken = &token.RefreshToken
 	}
 	if !token.Expiry.IsZero() {
-		oauthAccount.TokenExpiresAt = &token.Expiry
-	}
-
-	if err := h.oauthRepo.CreateAccount(ctx, oauthAccount); err != nil {
-		return nil, fmt.Errorf("failed to create OAuth account: %w", err)
+		account.TokenExpiresAt = &token.Expiry
 	}
+	account.UpdatedAt = time.Now()
 
-	// Create session
-	session, err := h.authMW.CreateSession(ctx, user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	if err := h.oauthRepo.UpdateAccount(ctx, account); err != nil {
+		return fmt.Errorf("failed to update OAuth account: %w", err)
 	}
+	return nil
+}
 
+func newOAuthResponse(session *models.Session, user *models.User) *OAuthResponse {
 	return &OAuthResponse{
-		SessionToken: session.SessionToken,
-		User:         user.ToResponse(),
-		ExpiresAt:    session.ExpiresAt,
-	}, nil
+		SessionToken:     session.SessionToken,
+		RefreshToken:     session.RefreshToken,
+		User:             user.ToResponse(),
+		ExpiresAt:        session.AccessExpiresAt,
+		RefreshExpiresAt: session.ExpiresAt,
+	}
 }
 
-func (h *OAuthHandler) getStringFromMap(m map[string]interface{}, key string) string {
-	if val, ok := m[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
+func newOAuthAccount(userID uuid.UUID, provider models.OAuthProvider, userInfo *oauth.UserInfo, token *oauth2.Token) *models.OAuthAccount {
+	account := &models.OAuthAccount{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Provider:      provider,
+		ProviderID:    userInfo.ID,
+		ProviderEmail: &userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		ProviderName:  &userInfo.Name,
+		AccessToken:   &token.AccessToken,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if token.RefreshToken != "" {
+		account.RefreshToken = &token.RefreshToken
+	}
+	if !token.Expiry.IsZero() {
+		account.TokenExpiresAt = &token.Expiry
 	}
-	return ""
+
+	return account
 }
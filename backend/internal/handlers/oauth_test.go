@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
+	"golang.org/x/oauth2"
+)
+
+// fakeProvider signs everyone in as the same identity
+type fakeProvider struct {
+	info oauth.UserInfo
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) AuthCodeURL(ctx context.Context, state *models.OAuthState) (string, error) {
+	return "https://provider.example/auth?state=" + state.State, nil
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code string, state *models.OAuthState) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: "provider-token"}, nil
+}
+
+func (p *fakeProvider) UserInfo(ctx context.Context, token *oauth2.Token, state *models.OAuthState, params url.Values) (*oauth.UserInfo, error) {
+	return &p.info, nil
+}
+
+func newHandoffTestHandler(t *testing.T) *OAuthHandler {
+	t.Helper()
+	providers := oauth.NewRegistry()
+	if err := providers.Register(&fakeProvider{info: oauth.UserInfo{ID: "f-1", Email: "fay@example.com", EmailVerified: true}}); err != nil {
+		t.Fatal(err)
+	}
+	redirects, err := oauth.ParseRedirectAllowlist("pin://auth/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewOAuthHandler(NewMockOAuthRepository(), NewMockUserRepository(), NewMockSessionRepository(), providers, redirects)
+}
+
+// startSignIn runs Auth and returns the state it created
+func startSignIn(t *testing.T, h *OAuthHandler, redirectURL string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	req := withPathValue(httptest.NewRequest("GET", "/api/auth/fake?redirect_url="+url.QueryEscape(redirectURL), nil), "provider", "fake")
+	w := httptest.NewRecorder()
+	h.Auth(w, req)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, location.Query().Get("state")
+}
+
+func TestOAuthHandler_Auth_RedirectAllowlist(t *testing.T) {
+	h := newHandoffTestHandler(t)
+
+	if w, _ := startSignIn(t, h, "https://evil.example/steal"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a foreign redirect_url, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w, _ := startSignIn(t, h, "pin://auth/callback"); w.Code != http.StatusTemporaryRedirect {
+		t.Errorf("expected status %d for an allowed redirect_url, got %d", http.StatusTemporaryRedirect, w.Code)
+	}
+}
+
+func TestOAuthHandler_CallbackHandoff(t *testing.T) {
+	h := newHandoffTestHandler(t)
+	_, state := startSignIn(t, h, "pin://auth/callback")
+
+	req := withPathValue(httptest.NewRequest("GET", "/api/auth/fake/callback?code=abc&state="+state, nil), "provider", "fake")
+	w := httptest.NewRecorder()
+	h.Callback(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusFound, w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "session_token") {
+		t.Error("callback must not return tokens")
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || location.Scheme != "pin" || location.Host != "auth" {
+		t.Fatalf("expected a redirect to the app, got %q", w.Header().Get("Location"))
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		t.Fatalf("expected a code in %q", location)
+	}
+
+	exchange := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/exchange", bytes.NewBufferString(`{"code": "`+code+`"}`))
+		w := httptest.NewRecorder()
+		h.Exchange(w, req)
+		return w
+	}
+
+	w = exchange()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp OAuthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SessionToken == "" || resp.User.Email != "fay@example.com" {
+		t.Errorf("expected a session for fay@example.com, got %+v", resp)
+	}
+
+	if w := exchange(); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a used code to be refused, got %d", w.Code)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
 	"github.com/pin-app/pin/internal/repository"
 	"github.com/pin-app/pin/internal/server"
@@ -26,6 +27,13 @@ func NewPlaceHandler(placeRepo repository.PlaceRepository) *PlaceHandler {
 }
 
 func (h *PlaceHandler) CreatePlace(w http.ResponseWriter, r *http.Request) {
+	// Get authenticated user ID from context
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
 	var req models.PlaceCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
@@ -42,6 +50,7 @@ func (h *PlaceHandler) CreatePlace(w http.ResponseWriter, r *http.Request) {
 		Name:       req.Name,
 		Geometry:   req.Geometry,
 		Properties: req.Properties,
+		CreatedBy:  &userID,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
@@ -55,8 +64,7 @@ func (h *PlaceHandler) CreatePlace(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PlaceHandler) GetPlace(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/places/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -72,8 +80,7 @@ func (h *PlaceHandler) GetPlace(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PlaceHandler) UpdatePlace(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/places/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -96,6 +103,10 @@ func (h *PlaceHandler) UpdatePlace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := authorize(w, r, placeOwner(place), models.RoleModerator); !ok {
+		return
+	}
+
 	if req.Name != nil {
 		place.Name = *req.Name
 	}
@@ -116,14 +127,24 @@ func (h *PlaceHandler) UpdatePlace(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PlaceHandler) DeletePlace(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/places/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
 	}
 
-	if err := h.placeRepo.Delete(r.Context(), id); err != nil {
+	place, err := h.placeRepo.GetByID(r.Context(), id)
+	if err != nil {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Place not found"})
+		return
+	}
+
+	userID, ok := authorize(w, r, placeOwner(place), models.RoleModerator)
+	if !ok {
+		return
+	}
+
+	if err := h.placeRepo.Delete(r.Context(), id, userID); err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Place not found"})
 		return
 	}
@@ -271,3 +292,12 @@ func (h *PlaceHandler) SearchNearbyPlaces(w http.ResponseWriter, r *http.Request
 		"count":  len(responses),
 	})
 }
+
+// placeOwner returns the user who created the place, or uuid.Nil for places
+// that predate ownership tracking
+func placeOwner(place *models.Place) uuid.UUID {
+	if place.CreatedBy == nil {
+		return uuid.Nil
+	}
+	return *place.CreatedBy
+}
@@ -3,14 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/metrics"
 	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
 	"github.com/pin-app/pin/internal/repository"
@@ -76,9 +76,13 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		UserID:      userID,
 		PlaceID:     req.PlaceID,
 		Description: req.Description,
+		Visibility:  models.PostVisibilityPublic,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	if req.Visibility != nil {
+		post.Visibility = *req.Visibility
+	}
 
 	if err := h.postRepo.Create(r.Context(), post); err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create post"})
@@ -103,20 +107,22 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	metrics.PostsCreated.Inc()
+
 	// Get post with images for response
 	postResponse := h.buildPostResponse(r.Context(), post)
 	server.WriteJSON(w, http.StatusCreated, postResponse)
 }
 
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/posts/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
 	}
 
-	post, err := h.postRepo.GetByID(r.Context(), id)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	post, err := h.postRepo.GetVisibleByID(r.Context(), id, viewerID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
 		return
@@ -127,8 +133,7 @@ func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/posts/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
@@ -152,12 +157,17 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: Check if user owns the post
+	if _, ok := authorize(w, r, post.UserID, models.RoleAdmin); !ok {
+		return
+	}
 
 	// Update fields
 	if req.Description != nil {
 		post.Description = req.Description
 	}
+	if req.Visibility != nil {
+		post.Visibility = *req.Visibility
+	}
 	post.UpdatedAt = time.Now()
 
 	if err := h.postRepo.Update(r.Context(), post); err != nil {
@@ -170,16 +180,24 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/posts/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
 	}
 
-	// TODO: Check if user owns the post
+	post, err := h.postRepo.GetByID(r.Context(), id)
+	if err != nil {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	userID, ok := authorize(w, r, post.UserID, models.RoleModerator)
+	if !ok {
+		return
+	}
 
-	if err := h.postRepo.Delete(r.Context(), id); err != nil {
+	if err := h.postRepo.Delete(r.Context(), id, userID); err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
 		return
 	}
@@ -227,9 +245,7 @@ func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) ListPostsByUser(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	userIDStr := path[len("/api/users/") : len(path)-len("/posts")]
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -253,7 +269,8 @@ func (h *PostHandler) ListPostsByUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	posts, err := h.postRepo.ListByUserID(r.Context(), userID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	posts, err := h.postRepo.ListByUserID(r.Context(), userID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list user posts"})
 		return
@@ -274,9 +291,7 @@ func (h *PostHandler) ListPostsByUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) ListPostsByPlace(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	placeIDStr := path[len("/api/places/") : len(path)-len("/posts")]
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -300,7 +315,8 @@ func (h *PostHandler) ListPostsByPlace(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	posts, err := h.postRepo.ListByPlaceID(r.Context(), placeID, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	posts, err := h.postRepo.ListByPlaceID(r.Context(), placeID, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list place posts"})
 		return
@@ -375,22 +391,28 @@ func (h *PostHandler) LikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	postID, err := h.extractPostIDFromLikesPath(r.URL.Path)
+	postID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
 	}
 
-	post, err := h.postRepo.GetByID(r.Context(), postID)
+	// a post the user can't see can't be liked either
+	post, err := h.postRepo.GetVisibleByID(r.Context(), postID, userID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
 		return
 	}
 
 	if err := h.likeRepo.LikePost(r.Context(), postID, userID); err != nil {
+		if errors.Is(err, repository.ErrBlocked) {
+			server.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "You can't like this post"})
+			return
+		}
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to like post"})
 		return
 	}
+	metrics.Likes.Inc()
 
 	if post.UserID != userID && h.notificationRepo != nil {
 		data := map[string]string{
@@ -421,7 +443,7 @@ func (h *PostHandler) UnlikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	postID, err := h.extractPostIDFromLikesPath(r.URL.Path)
+	postID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 		return
@@ -457,18 +479,6 @@ func (h *PostHandler) UnlikePost(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *PostHandler) extractPostIDFromLikesPath(path string) (uuid.UUID, error) {
-	const prefix = "/api/posts/"
-	const suffix = "/likes"
-
-	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
-		return uuid.Nil, fmt.Errorf("invalid likes path")
-	}
-
-	idStr := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
-	return uuid.Parse(idStr)
-}
-
 func (h *PostHandler) createNotification(ctx context.Context, notification *models.Notification) error {
 	if h.notificationRepo == nil {
 		return nil
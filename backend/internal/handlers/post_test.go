@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockPostRepository is a mock implementation of PostRepository for testing.
+// GetVisibleByID applies post visibility against follows.
+type MockPostRepository struct {
+	posts   map[uuid.UUID]*models.Post
+	follows *MockFollowRepository
+}
+
+func NewMockPostRepository(follows *MockFollowRepository) *MockPostRepository {
+	return &MockPostRepository{
+		posts:   make(map[uuid.UUID]*models.Post),
+		follows: follows,
+	}
+}
+
+func (m *MockPostRepository) Create(ctx context.Context, post *models.Post) error {
+	m.posts[post.ID] = post
+	return nil
+}
+
+func (m *MockPostRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	post, exists := m.posts[id]
+	if !exists {
+		return nil, repository.ErrPostNotFound
+	}
+	return post, nil
+}
+
+func (m *MockPostRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Post, error) {
+	post, err := m.GetByID(ctx, id)
+	if err != nil || post.UserID == viewerID {
+		return post, err
+	}
+
+	switch post.Visibility {
+	case models.PostVisibilityPublic:
+		return post, nil
+	case models.PostVisibilityFollowers:
+		if following, _ := m.follows.IsFollowing(ctx, viewerID, post.UserID); following {
+			return post, nil
+		}
+	}
+	return nil, repository.ErrPostNotFound
+}
+
+func (m *MockPostRepository) Update(ctx context.Context, post *models.Post) error {
+	m.posts[post.ID] = post
+	return nil
+}
+
+func (m *MockPostRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
+	delete(m.posts, id)
+	return nil
+}
+
+func (m *MockPostRepository) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+	return nil, nil
+}
+
+func (m *MockPostRepository) ListByPlaceID(ctx context.Context, placeID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+	return nil, nil
+}
+
+func (m *MockPostRepository) ListFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+	return nil, nil
+}
+
+func (m *MockPostRepository) CreateImage(ctx context.Context, image *models.PostImage) error {
+	return nil
+}
+
+func (m *MockPostRepository) GetImagesByPostID(ctx context.Context, postID uuid.UUID) ([]*models.PostImage, error) {
+	return nil, nil
+}
+
+func (m *MockPostRepository) UpdateImage(ctx context.Context, image *models.PostImage) error {
+	return nil
+}
+
+func (m *MockPostRepository) DeleteImage(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (m *MockPostRepository) DeleteImagesByPostID(ctx context.Context, postID uuid.UUID) error {
+	return nil
+}
+
+// MockLikeRepository is a mock implementation of LikeRepository for testing
+type MockLikeRepository struct {
+	likes map[[2]uuid.UUID]bool
+}
+
+func NewMockLikeRepository() *MockLikeRepository {
+	return &MockLikeRepository{likes: make(map[[2]uuid.UUID]bool)}
+}
+
+func (m *MockLikeRepository) LikePost(ctx context.Context, postID, userID uuid.UUID) error {
+	m.likes[[2]uuid.UUID{postID, userID}] = true
+	return nil
+}
+
+func (m *MockLikeRepository) UnlikePost(ctx context.Context, postID, userID uuid.UUID) error {
+	delete(m.likes, [2]uuid.UUID{postID, userID})
+	return nil
+}
+
+func (m *MockLikeRepository) IsPostLikedByUser(ctx context.Context, postID, userID uuid.UUID) (bool, error) {
+	return m.likes[[2]uuid.UUID{postID, userID}], nil
+}
+
+func (m *MockLikeRepository) CountPostLikes(ctx context.Context, postID uuid.UUID) (int, error) {
+	count := 0
+	for key := range m.likes {
+		if key[0] == postID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MockPlaceRepository is a mock implementation of PlaceRepository for testing
+type MockPlaceRepository struct {
+	places map[uuid.UUID]*models.Place
+}
+
+func NewMockPlaceRepository() *MockPlaceRepository {
+	return &MockPlaceRepository{places: make(map[uuid.UUID]*models.Place)}
+}
+
+func (m *MockPlaceRepository) Create(ctx context.Context, place *models.Place) error {
+	m.places[place.ID] = place
+	return nil
+}
+
+func (m *MockPlaceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Place, error) {
+	place, exists := m.places[id]
+	if !exists {
+		return nil, repository.ErrPlaceNotFound
+	}
+	return place, nil
+}
+
+func (m *MockPlaceRepository) Update(ctx context.Context, place *models.Place) error {
+	m.places[place.ID] = place
+	return nil
+}
+
+func (m *MockPlaceRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
+	delete(m.places, id)
+	return nil
+}
+
+func (m *MockPlaceRepository) List(ctx context.Context, limit, offset int) ([]*models.Place, error) {
+	return nil, nil
+}
+
+func (m *MockPlaceRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.Place, error) {
+	return nil, nil
+}
+
+func (m *MockPlaceRepository) SearchNearby(ctx context.Context, lat, lng float64, radiusKm float64, limit int) ([]*models.Place, error) {
+	return nil, nil
+}
+
+func (m *MockPlaceRepository) CreateRelation(ctx context.Context, relation *models.PlaceRelation) error {
+	return nil
+}
+
+func (m *MockPlaceRepository) GetRelations(ctx context.Context, placeID uuid.UUID) ([]*models.PlaceRelation, error) {
+	return nil, nil
+}
+
+func (m *MockPlaceRepository) DeleteRelation(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+type postTestData struct {
+	handler   *PostHandler
+	posts     *MockPostRepository
+	likes     *MockLikeRepository
+	follows   *MockFollowRepository
+	authorID  uuid.UUID
+	follower  uuid.UUID
+	stranger  uuid.UUID
+	placeID   uuid.UUID
+	byVisible map[models.PostVisibility]*models.Post
+}
+
+// newPostTestData sets up an author with one post of each visibility, one
+// follower and one stranger
+func newPostTestData() *postTestData {
+	ctx := context.Background()
+	d := &postTestData{
+		follows:   NewMockFollowRepository(),
+		likes:     NewMockLikeRepository(),
+		authorID:  uuid.New(),
+		follower:  uuid.New(),
+		stranger:  uuid.New(),
+		placeID:   uuid.New(),
+		byVisible: make(map[models.PostVisibility]*models.Post),
+	}
+	d.posts = NewMockPostRepository(d.follows)
+
+	userRepo := NewMockUserRepository()
+	userRepo.Create(ctx, &models.User{ID: d.authorID, Email: "author@example.com"})
+	placeRepo := NewMockPlaceRepository()
+	placeRepo.Create(ctx, &models.Place{ID: d.placeID, Name: "Somewhere"})
+	d.follows.CreateFollow(ctx, &models.Follow{FollowerID: d.follower, FollowingID: d.authorID, Status: models.FollowAccepted})
+
+	for _, visibility := range []models.PostVisibility{models.PostVisibilityPublic, models.PostVisibilityFollowers, models.PostVisibilityOnlyMe} {
+		post := &models.Post{ID: uuid.New(), UserID: d.authorID, PlaceID: d.placeID, Visibility: visibility}
+		d.posts.Create(ctx, post)
+		d.byVisible[visibility] = post
+	}
+
+	d.handler = NewPostHandler(d.posts, placeRepo, userRepo, nil, d.likes, nil)
+	return d
+}
+
+func TestPostHandler_GetPost_Visibility(t *testing.T) {
+	d := newPostTestData()
+
+	tests := []struct {
+		name       string
+		visibility models.PostVisibility
+		viewerID   uuid.UUID
+		wantStatus int
+	}{
+		{"public to a stranger", models.PostVisibilityPublic, d.stranger, http.StatusOK},
+		{"followers to a stranger", models.PostVisibilityFollowers, d.stranger, http.StatusNotFound},
+		{"followers to a follower", models.PostVisibilityFollowers, d.follower, http.StatusOK},
+		{"only_me to a stranger", models.PostVisibilityOnlyMe, d.stranger, http.StatusNotFound},
+		{"only_me to a follower", models.PostVisibilityOnlyMe, d.follower, http.StatusNotFound},
+		{"only_me to the author", models.PostVisibilityOnlyMe, d.authorID, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := d.byVisible[tt.visibility].ID.String()
+			req := withPathValue(httptest.NewRequest("GET", "/api/posts/"+id, nil), "id", id)
+			req = withUser(req, tt.viewerID)
+			rr := httptest.NewRecorder()
+			d.handler.GetPost(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("GetPost() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPostHandler_LikePost_Hidden(t *testing.T) {
+	d := newPostTestData()
+
+	for _, visibility := range []models.PostVisibility{models.PostVisibilityFollowers, models.PostVisibilityOnlyMe} {
+		post := d.byVisible[visibility]
+		req := withPathValue(httptest.NewRequest("POST", "/api/posts/"+post.ID.String()+"/like", nil), "id", post.ID.String())
+		req = withUser(req, d.stranger)
+		rr := httptest.NewRecorder()
+		d.handler.LikePost(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("LikePost() on a %s post status = %v, want %v", visibility, rr.Code, http.StatusNotFound)
+		}
+		if liked, _ := d.likes.IsPostLikedByUser(context.Background(), post.ID, d.stranger); liked {
+			t.Errorf("LikePost() liked a %s post the user can't see", visibility)
+		}
+	}
+}
+
+func TestPostHandler_InvalidVisibility(t *testing.T) {
+	d := newPostTestData()
+	body := `{"place_id": "` + d.placeID.String() + `", "visibility": "friends"}`
+
+	req := withUser(httptest.NewRequest("POST", "/api/posts", bytes.NewBufferString(body)), d.authorID)
+	rr := httptest.NewRecorder()
+	d.handler.CreatePost(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreatePost() status = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	post := d.byVisible[models.PostVisibilityPublic]
+	req = withPathValue(httptest.NewRequest("PUT", "/api/posts/"+post.ID.String(), bytes.NewBufferString(`{"visibility": "friends"}`)), "id", post.ID.String())
+	req = withUser(req, d.authorID)
+	rr = httptest.NewRecorder()
+	d.handler.UpdatePost(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("UpdatePost() status = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+	if post.Visibility != models.PostVisibilityPublic {
+		t.Errorf("UpdatePost() stored visibility %q", post.Visibility)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/metrics"
 	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
 	"github.com/pin-app/pin/internal/repository"
@@ -38,8 +39,7 @@ func (h *RatingHandler) CreateRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -87,8 +87,7 @@ func (h *RatingHandler) GetRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -111,8 +110,7 @@ func (h *RatingHandler) UpdateRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -152,8 +150,7 @@ func (h *RatingHandler) DeleteRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -168,8 +165,7 @@ func (h *RatingHandler) DeleteRating(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RatingHandler) ListRatingsByPlace(w http.ResponseWriter, r *http.Request) {
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -209,8 +205,7 @@ func (h *RatingHandler) ListRatingsByPlace(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *RatingHandler) GetAverageRating(w http.ResponseWriter, r *http.Request) {
-	placeIDStr := r.URL.Path[len("/api/places/") : len("/api/places/")+36] // UUID length
-	placeID, err := uuid.Parse(placeIDStr)
+	placeID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid place ID"})
 		return
@@ -273,13 +268,13 @@ func (h *RatingHandler) CreateComparison(w http.ResponseWriter, r *http.Request)
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create comparison"})
 		return
 	}
+	metrics.Comparisons.Inc()
 
 	server.WriteJSON(w, http.StatusCreated, comparison)
 }
 
 func (h *RatingHandler) ListComparisonsByUser(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Path[len("/api/users/") : len("/api/users/")+36] // UUID length
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"fmt"
+
+	"github.com/pin-app/pin/internal/config"
 	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/mail"
 	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oauth"
+	"github.com/pin-app/pin/internal/ratelimit"
 	"github.com/pin-app/pin/internal/repository"
 	"github.com/pin-app/pin/internal/server"
 )
 
-func RegisterRoutes(srv *server.Server, db *database.DB, uploadDir string) {
+// RegisterRoutes wires every handler into srv. It fails if a sign in
+// provider or the mailer can't be built from cfg.
+func RegisterRoutes(srv *server.Server, db *database.DB, cfg *config.Config) error {
 	router := srv.GetRouter()
 
 	// Initialize repositories
@@ -16,92 +25,159 @@ func RegisterRoutes(srv *server.Server, db *database.DB, uploadDir string) {
 	postRepo := repository.NewPostRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
 	ratingRepo := repository.NewRatingRepository(db)
-	oauthRepo := repository.NewOAuthRepository(db)
+	oauthRepo := repository.NewOAuthRepository(db, cfg.TokenKeys)
 	sessionRepo := repository.NewSessionRepository(db)
+	tokenRepo := repository.NewAccessTokenRepository(db)
+	magicLinkRepo := repository.NewMagicLinkRepository(db)
 	followRepo := repository.NewFollowRepository(db)
 	likeRepo := repository.NewLikeRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
+	exportRepo := repository.NewDataExportRepository(db)
+	blockRepo := repository.NewBlockRepository(db)
 
 	// Initialize auth middleware
-	authMW := middleware.NewAuthMiddleware(sessionRepo, userRepo)
+	authMW := middleware.NewAuthMiddleware(sessionRepo, userRepo, tokenRepo, cfg.DevMode)
+
+	// Sign in providers; callbacks only hand off to the allowlisted app URLs
+	providers, err := oauth.NewRegistryFromConfig(cfg.OAuth.Providers)
+	if err != nil {
+		return fmt.Errorf("invalid OAuth provider configuration: %w", err)
+	}
+
+	// Email sign in links point at the app, which posts the token back
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("invalid mail configuration: %w", err)
+	}
+
+	// Throttle sign in and sign up
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), cfg.RateLimits)
 
 	// Initialize handlers
 	userHandler := NewUserHandler(userRepo)
 	placeHandler := NewPlaceHandler(placeRepo)
 	postHandler := NewPostHandler(postRepo, placeRepo, userRepo, commentRepo, likeRepo, notificationRepo)
 	commentHandler := NewCommentHandler(commentRepo, postRepo, userRepo, notificationRepo)
-	uploadHandler := NewUploadHandler(uploadDir)
+	uploadHandler := NewUploadHandler(cfg.UploadDir)
 	ratingHandler := NewRatingHandler(ratingRepo, placeRepo, userRepo)
-	oauthHandler := NewOAuthHandler(oauthRepo, userRepo, sessionRepo)
+	sessionHandler := NewSessionHandler(sessionRepo)
+	tokenHandler := NewAccessTokenHandler(tokenRepo)
+	oauthHandler := NewOAuthHandler(oauthRepo, userRepo, sessionRepo, providers, cfg.OAuth.RedirectAllowlist)
+	magicLinkHandler := NewMagicLinkHandler(magicLinkRepo, userRepo, authMW, mailer, cfg.MagicLinkURL)
 	followHandler := NewFollowHandler(followRepo, userRepo)
+	blockHandler := NewBlockHandler(blockRepo, userRepo)
 	notificationHandler := NewNotificationHandler(notificationRepo, userRepo)
+	exportHandler := NewDataExportHandler(exportRepo, cfg.ExportDir)
 
-	// OAuth routes (public)
 	// Upload routes
-	router.HandleFunc("/api/uploads", "POST", authMW.RequireAuth(uploadHandler.UploadImage))
+	router.HandleFunc("/api/uploads", "POST", authMW.RequireScope(models.ScopePostsWrite, uploadHandler.UploadImage))
+
+	// Sign in routes (public)
+	auth := router.Group("/api/auth", limiter.Middleware(ratelimit.GroupAuth))
+	authToken := router.Group("/api/auth", limiter.Middleware(ratelimit.GroupAuthToken))
+	auth.HandleFunc("/providers", "GET", oauthHandler.ListProviders)
+	authToken.HandleFunc("/magic-link", "POST", magicLinkHandler.Request)
+	authToken.HandleFunc("/magic-link/redeem", "POST", magicLinkHandler.Redeem)
+	auth.HandleFunc("/{provider}", "GET", oauthHandler.Auth)
+	auth.HandleFunc("/{provider}/callback", "GET", oauthHandler.Callback)
+	auth.HandleFunc("/{provider}/callback", "POST", oauthHandler.Callback)
+	router.HandleFunc("/api/auth/{provider}/link", "POST", authMW.RequireAuth(limiter.Limit(ratelimit.GroupAuth, oauthHandler.Link)))
+	authToken.HandleFunc("/exchange", "POST", oauthHandler.Exchange)
+	authToken.HandleFunc("/refresh", "POST", oauthHandler.Refresh)
+	auth.HandleFunc("/logout", "POST", oauthHandler.Logout)
 
-	router.HandleFunc("/api/auth/google", "GET", oauthHandler.GoogleAuth)
-	router.HandleFunc("/api/auth/google/callback", "GET", oauthHandler.GoogleCallback)
-	router.HandleFunc("/api/auth/apple", "GET", oauthHandler.AppleAuth)
-	router.HandleFunc("/api/auth/apple/callback", "GET", oauthHandler.AppleCallback)
-	router.HandleFunc("/api/auth/logout", "POST", oauthHandler.Logout)
+	// Account routes; these take a signed in user but no particular scope
+	account := router.Group("/api", authMW.RequireAuth)
+	account.HandleFunc("/identities", "GET", oauthHandler.ListIdentities)
+	account.HandleFunc("/identities/{id}", "DELETE", oauthHandler.UnlinkIdentity)
+
+	// Session routes
+	account.HandleFunc("/sessions", "GET", sessionHandler.ListSessions)
+	account.HandleFunc("/sessions", "DELETE", sessionHandler.RevokeOtherSessions)
+	account.HandleFunc("/sessions/{id}", "DELETE", sessionHandler.RevokeSession)
+
+	// Personal access token routes; only a session can manage tokens
+	account.HandleFunc("/tokens", "GET", tokenHandler.ListTokens)
+	account.HandleFunc("/tokens", "POST", tokenHandler.CreateToken)
+	account.HandleFunc("/tokens/{id}", "DELETE", tokenHandler.RevokeToken)
 
 	// User routes
-	router.HandleFunc("/api/users", "POST", userHandler.CreateUser)
-	router.HandleFunc("/api/users", "GET", authMW.OptionalAuth(userHandler.ListUsers))
-	router.HandleFunc("/api/users/search", "GET", authMW.OptionalAuth(userHandler.SearchUsers))
-	router.HandleFunc("/api/users/{id}", "GET", authMW.OptionalAuth(userHandler.GetUser))
-	router.HandleFunc("/api/users/{id}", "PUT", authMW.RequireAuth(userHandler.UpdateUser))
-	router.HandleFunc("/api/users/{id}", "DELETE", authMW.RequireAuth(userHandler.DeleteUser))
+	router.HandleFunc("/api/users", "POST", limiter.Limit(ratelimit.GroupSignup, userHandler.CreateUser))
+	router.HandleFunc("/api/users", "GET", authMW.OptionalScope(models.ScopeProfileRead, userHandler.ListUsers))
+	router.HandleFunc("/api/users/search", "GET", authMW.OptionalScope(models.ScopeProfileRead, userHandler.SearchUsers))
+	router.HandleFunc("/api/users/{id}", "GET", authMW.OptionalScope(models.ScopeProfileRead, userHandler.GetUser))
+	router.HandleFunc("/api/users/{id}", "PUT", authMW.RequireScope(models.ScopeProfileWrite, userHandler.UpdateUser))
+	account.HandleFunc("/users/{id}", "DELETE", userHandler.DeleteUser)
+	account.HandleFunc("/users/{id}/deletion", "DELETE", userHandler.CancelDeletion)
+	router.HandleFunc("/api/users/{id}/role", "PUT", authMW.RequireRole(models.RoleAdmin, userHandler.UpdateUserRole))
+
+	// Data export routes; archives are built in the background
+	account.HandleFunc("/exports", "POST", limiter.Limit(ratelimit.GroupExport, exportHandler.RequestExport))
+	account.HandleFunc("/exports", "GET", exportHandler.ListExports)
+	account.HandleFunc("/exports/{id}", "GET", exportHandler.GetExport)
+	account.HandleFunc("/exports/{id}/download", "GET", exportHandler.DownloadExport)
 
 	// Follow routes
-	router.HandleFunc("/api/users/{id}/follow", "POST", authMW.RequireAuth(followHandler.FollowUser))
-	router.HandleFunc("/api/users/{id}/follow", "DELETE", authMW.RequireAuth(followHandler.UnfollowUser))
-	router.HandleFunc("/api/users/{id}/following", "GET", authMW.OptionalAuth(followHandler.GetFollowing))
-	router.HandleFunc("/api/users/{id}/followers", "GET", authMW.OptionalAuth(followHandler.GetFollowers))
-	router.HandleFunc("/api/users/{id}/follow-status", "GET", authMW.RequireAuth(followHandler.CheckFollowStatus))
-	router.HandleFunc("/api/users/{id}/stats", "GET", authMW.OptionalAuth(followHandler.GetUserStats))
+	router.HandleFunc("/api/users/{id}/follow", "POST", authMW.RequireScope(models.ScopeFollowsWrite, followHandler.FollowUser))
+	router.HandleFunc("/api/users/{id}/follow", "DELETE", authMW.RequireScope(models.ScopeFollowsWrite, followHandler.UnfollowUser))
+	router.HandleFunc("/api/users/{id}/following", "GET", authMW.OptionalScope(models.ScopeFollowsRead, followHandler.GetFollowing))
+	router.HandleFunc("/api/users/{id}/followers", "GET", authMW.OptionalScope(models.ScopeFollowsRead, followHandler.GetFollowers))
+	router.HandleFunc("/api/users/{id}/follow-status", "GET", authMW.RequireScope(models.ScopeFollowsRead, followHandler.CheckFollowStatus))
+	router.HandleFunc("/api/users/{id}/stats", "GET", authMW.OptionalScope(models.ScopeFollowsRead, followHandler.GetUserStats))
+	router.HandleFunc("/api/follow-requests", "GET", authMW.RequireScope(models.ScopeFollowsRead, followHandler.ListFollowRequests))
+	router.HandleFunc("/api/follow-requests/{id}/approve", "POST", authMW.RequireScope(models.ScopeFollowsWrite, followHandler.ApproveFollowRequest))
+	router.HandleFunc("/api/follow-requests/{id}/deny", "POST", authMW.RequireScope(models.ScopeFollowsWrite, followHandler.DenyFollowRequest))
+
+	// Block and mute routes
+	router.HandleFunc("/api/users/{id}/block", "POST", authMW.RequireScope(models.ScopeFollowsWrite, blockHandler.BlockUser))
+	router.HandleFunc("/api/users/{id}/block", "DELETE", authMW.RequireScope(models.ScopeFollowsWrite, blockHandler.UnblockUser))
+	router.HandleFunc("/api/users/{id}/mute", "POST", authMW.RequireScope(models.ScopeFollowsWrite, blockHandler.MuteUser))
+	router.HandleFunc("/api/users/{id}/mute", "DELETE", authMW.RequireScope(models.ScopeFollowsWrite, blockHandler.UnmuteUser))
+	router.HandleFunc("/api/blocks", "GET", authMW.RequireScope(models.ScopeFollowsRead, blockHandler.ListBlocked))
+	router.HandleFunc("/api/mutes", "GET", authMW.RequireScope(models.ScopeFollowsRead, blockHandler.ListMuted))
 
 	// Place routes
-	router.HandleFunc("/api/places", "POST", authMW.RequireAuth(placeHandler.CreatePlace))
+	router.HandleFunc("/api/places", "POST", authMW.RequireScope(models.ScopePlacesWrite, placeHandler.CreatePlace))
 	router.HandleFunc("/api/places", "GET", authMW.OptionalAuth(placeHandler.ListPlaces))
 	router.HandleFunc("/api/places/search", "GET", authMW.OptionalAuth(placeHandler.SearchPlaces))
 	router.HandleFunc("/api/places/nearby", "GET", authMW.OptionalAuth(placeHandler.SearchNearbyPlaces))
 	router.HandleFunc("/api/places/{id}", "GET", authMW.OptionalAuth(placeHandler.GetPlace))
-	router.HandleFunc("/api/places/{id}", "PUT", authMW.RequireAuth(placeHandler.UpdatePlace))
-	router.HandleFunc("/api/places/{id}", "DELETE", authMW.RequireAuth(placeHandler.DeletePlace))
+	router.HandleFunc("/api/places/{id}", "PUT", authMW.RequireScope(models.ScopePlacesWrite, placeHandler.UpdatePlace))
+	router.HandleFunc("/api/places/{id}", "DELETE", authMW.RequireScope(models.ScopePlacesWrite, placeHandler.DeletePlace))
 
 	// Post routes
-	router.HandleFunc("/api/posts", "POST", authMW.RequireAuth(postHandler.CreatePost))
-	router.HandleFunc("/api/posts", "GET", authMW.OptionalAuth(postHandler.ListPosts))
-	router.HandleFunc("/api/posts/{id}", "GET", authMW.OptionalAuth(postHandler.GetPost))
-	router.HandleFunc("/api/posts/{id}", "PUT", authMW.RequireAuth(postHandler.UpdatePost))
-	router.HandleFunc("/api/posts/{id}", "DELETE", authMW.RequireAuth(postHandler.DeletePost))
-	router.HandleFunc("/api/posts/{id}/likes", "POST", authMW.RequireAuth(postHandler.LikePost))
-	router.HandleFunc("/api/posts/{id}/likes", "DELETE", authMW.RequireAuth(postHandler.UnlikePost))
-	router.HandleFunc("/api/users/{id}/posts", "GET", authMW.OptionalAuth(postHandler.ListPostsByUser))
-	router.HandleFunc("/api/places/{id}/posts", "GET", authMW.OptionalAuth(postHandler.ListPostsByPlace))
+	router.HandleFunc("/api/posts", "POST", authMW.RequireScope(models.ScopePostsWrite, postHandler.CreatePost))
+	router.HandleFunc("/api/posts", "GET", authMW.OptionalScope(models.ScopePostsRead, postHandler.ListPosts))
+	router.HandleFunc("/api/posts/{id}", "GET", authMW.OptionalScope(models.ScopePostsRead, postHandler.GetPost))
+	router.HandleFunc("/api/posts/{id}", "PUT", authMW.RequireScope(models.ScopePostsWrite, postHandler.UpdatePost))
+	router.HandleFunc("/api/posts/{id}", "DELETE", authMW.RequireScope(models.ScopePostsWrite, postHandler.DeletePost))
+	router.HandleFunc("/api/posts/{id}/likes", "POST", authMW.RequireScope(models.ScopePostsWrite, postHandler.LikePost))
+	router.HandleFunc("/api/posts/{id}/likes", "DELETE", authMW.RequireScope(models.ScopePostsWrite, postHandler.UnlikePost))
+	router.HandleFunc("/api/users/{id}/posts", "GET", authMW.OptionalScope(models.ScopePostsRead, postHandler.ListPostsByUser))
+	router.HandleFunc("/api/places/{id}/posts", "GET", authMW.OptionalScope(models.ScopePostsRead, postHandler.ListPostsByPlace))
 
 	// Comment routes
-	router.HandleFunc("/api/comments", "POST", authMW.RequireAuth(commentHandler.CreateComment))
-	router.HandleFunc("/api/comments/{id}", "GET", authMW.OptionalAuth(commentHandler.GetComment))
-	router.HandleFunc("/api/comments/{id}", "PUT", authMW.RequireAuth(commentHandler.UpdateComment))
-	router.HandleFunc("/api/comments/{id}", "DELETE", authMW.RequireAuth(commentHandler.DeleteComment))
-	router.HandleFunc("/api/comments/{id}/replies", "GET", authMW.OptionalAuth(commentHandler.GetCommentReplies))
-	router.HandleFunc("/api/posts/{id}/comments", "GET", authMW.OptionalAuth(commentHandler.ListCommentsByPost))
-	router.HandleFunc("/api/users/{id}/comments", "GET", authMW.OptionalAuth(commentHandler.ListCommentsByUser))
+	router.HandleFunc("/api/comments", "POST", authMW.RequireScope(models.ScopeCommentsWrite, commentHandler.CreateComment))
+	router.HandleFunc("/api/comments/{id}", "GET", authMW.OptionalScope(models.ScopeCommentsRead, commentHandler.GetComment))
+	router.HandleFunc("/api/comments/{id}", "PUT", authMW.RequireScope(models.ScopeCommentsWrite, commentHandler.UpdateComment))
+	router.HandleFunc("/api/comments/{id}", "DELETE", authMW.RequireScope(models.ScopeCommentsWrite, commentHandler.DeleteComment))
+	router.HandleFunc("/api/comments/{id}/replies", "GET", authMW.OptionalScope(models.ScopeCommentsRead, commentHandler.GetCommentReplies))
+	router.HandleFunc("/api/posts/{id}/comments", "GET", authMW.OptionalScope(models.ScopeCommentsRead, commentHandler.ListCommentsByPost))
+	router.HandleFunc("/api/users/{id}/comments", "GET", authMW.OptionalScope(models.ScopeCommentsRead, commentHandler.ListCommentsByUser))
 
 	// Notification routes
-	router.HandleFunc("/api/notifications", "GET", authMW.RequireAuth(notificationHandler.ListNotifications))
-	router.HandleFunc("/api/notifications/clear", "POST", authMW.RequireAuth(notificationHandler.ClearNotifications))
+	router.HandleFunc("/api/notifications", "GET", authMW.RequireScope(models.ScopeNotificationsRead, notificationHandler.ListNotifications))
+	router.HandleFunc("/api/notifications/clear", "POST", authMW.RequireScope(models.ScopeNotificationsWrite, notificationHandler.ClearNotifications))
 
 	// Rating routes
-	router.HandleFunc("/api/places/{id}/ratings", "POST", authMW.RequireAuth(ratingHandler.CreateRating))
-	router.HandleFunc("/api/places/{id}/ratings", "GET", authMW.OptionalAuth(ratingHandler.ListRatingsByPlace))
-	router.HandleFunc("/api/places/{id}/ratings", "PUT", authMW.RequireAuth(ratingHandler.UpdateRating))
-	router.HandleFunc("/api/places/{id}/ratings", "DELETE", authMW.RequireAuth(ratingHandler.DeleteRating))
-	router.HandleFunc("/api/places/{id}/ratings/me", "GET", authMW.RequireAuth(ratingHandler.GetRating))
-	router.HandleFunc("/api/places/{id}/ratings/average", "GET", authMW.OptionalAuth(ratingHandler.GetAverageRating))
-	router.HandleFunc("/api/places/compare", "POST", authMW.RequireAuth(ratingHandler.CreateComparison))
-	router.HandleFunc("/api/users/{id}/comparisons", "GET", authMW.RequireAuth(ratingHandler.ListComparisonsByUser))
+	router.HandleFunc("/api/places/{id}/ratings", "POST", authMW.RequireScope(models.ScopeRatingsWrite, ratingHandler.CreateRating))
+	router.HandleFunc("/api/places/{id}/ratings", "GET", authMW.OptionalScope(models.ScopeRatingsRead, ratingHandler.ListRatingsByPlace))
+	router.HandleFunc("/api/places/{id}/ratings", "PUT", authMW.RequireScope(models.ScopeRatingsWrite, ratingHandler.UpdateRating))
+	router.HandleFunc("/api/places/{id}/ratings", "DELETE", authMW.RequireScope(models.ScopeRatingsWrite, ratingHandler.DeleteRating))
+	router.HandleFunc("/api/places/{id}/ratings/me", "GET", authMW.RequireScope(models.ScopeRatingsRead, ratingHandler.GetRating))
+	router.HandleFunc("/api/places/{id}/ratings/average", "GET", authMW.OptionalScope(models.ScopeRatingsRead, ratingHandler.GetAverageRating))
+	router.HandleFunc("/api/places/compare", "POST", authMW.RequireScope(models.ScopeRatingsWrite, ratingHandler.CreateComparison))
+	router.HandleFunc("/api/users/{id}/comparisons", "GET", authMW.RequireScope(models.ScopeRatingsRead, ratingHandler.ListComparisonsByUser))
+
+	return nil
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+	"github.com/pin-app/pin/internal/server"
+)
+
+type SessionHandler struct {
+	sessionRepo repository.SessionRepository
+}
+
+func NewSessionHandler(sessionRepo repository.SessionRepository) *SessionHandler {
+	return &SessionHandler{
+		sessionRepo: sessionRepo,
+	}
+}
+
+// ListSessions returns the caller's active sessions, one per signed in device
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := h.sessionRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get sessions"})
+		return
+	}
+
+	currentID := currentSessionID(r)
+	responses := make([]models.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = session.ToResponse()
+		responses[i].Current = session.ID == currentID
+	}
+
+	server.WriteJSON(w, http.StatusOK, responses)
+}
+
+// RevokeSession signs one of the caller's devices out. Revoking the current
+// session works like logging out.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.sessionRepo.DeleteForUser(r.Context(), id, userID); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to revoke session"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions signs the caller out everywhere except the session
+// making the request. Without one (a dev mode user) there's nothing to keep,
+// so it refuses rather than revoking them all.
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		server.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "User not authenticated"})
+		return
+	}
+
+	current := currentSessionID(r)
+	if current == uuid.Nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Request isn't made with a session"})
+		return
+	}
+
+	revoked, err := h.sessionRepo.DeleteByUserIDExcept(r.Context(), userID, current)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// currentSessionID is uuid.Nil when the request isn't backed by a session,
+// as with dev mode users
+func currentSessionID(r *http.Request) uuid.UUID {
+	if session, ok := middleware.GetSessionFromContext(r.Context()); ok {
+		return session.ID
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// MockSessionRepository is a mock implementation of SessionRepository for testing
+type MockSessionRepository struct {
+	sessions map[uuid.UUID]*models.Session
+	// refresh token -> session, and whether it has been rotated
+	refreshTokens map[string]uuid.UUID
+	usedTokens    map[string]bool
+}
+
+func NewMockSessionRepository() *MockSessionRepository {
+	return &MockSessionRepository{
+		sessions:      make(map[uuid.UUID]*models.Session),
+		refreshTokens: make(map[string]uuid.UUID),
+		usedTokens:    make(map[string]bool),
+	}
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	m.sessions[session.ID] = session
+	m.refreshTokens[session.RefreshToken] = session.ID
+	return nil
+}
+
+func (m *MockSessionRepository) Rotate(ctx context.Context, refreshToken, newAccessToken, newRefreshToken string, accessExpiresAt, expiresAt time.Time) (*models.Session, error) {
+	sessionID, ok := m.refreshTokens[refreshToken]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	if m.usedTokens[refreshToken] {
+		delete(m.sessions, sessionID)
+		return nil, repository.ErrRefreshTokenReused
+	}
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+
+	m.usedTokens[refreshToken] = true
+	m.refreshTokens[newRefreshToken] = sessionID
+	session.SessionToken = newAccessToken
+	session.RefreshToken = newRefreshToken
+	session.AccessExpiresAt = accessExpiresAt
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+func (m *MockSessionRepository) GetByToken(ctx context.Context, token string) (*models.Session, error) {
+	for _, s := range m.sessions {
+		if s.SessionToken == token {
+			return s, nil
+		}
+	}
+	return nil, repository.ErrSessionNotFound
+}
+
+func (m *MockSessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	var sessions []*models.Session
+	for _, s := range m.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *MockSessionRepository) Update(ctx context.Context, session *models.Session) error {
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func (m *MockSessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *MockSessionRepository) DeleteByToken(ctx context.Context, token string) error {
+	for id, s := range m.sessions {
+		if s.SessionToken == token {
+			delete(m.sessions, id)
+			return nil
+		}
+	}
+	return repository.ErrSessionNotFound
+}
+
+func (m *MockSessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := m.DeleteByUserIDExcept(ctx, userID, uuid.Nil)
+	return err
+}
+
+func (m *MockSessionRepository) DeleteForUser(ctx context.Context, id, userID uuid.UUID) error {
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID {
+		return repository.ErrSessionNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *MockSessionRepository) DeleteByUserIDExcept(ctx context.Context, userID, keepID uuid.UUID) (int64, error) {
+	var n int64
+	for id, s := range m.sessions {
+		if s.UserID == userID && id != keepID {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	if s, ok := m.sessions[id]; ok {
+		s.LastSeenAt = &seenAt
+	}
+	return nil
+}
+
+func (m *MockSessionRepository) CleanupExpired(ctx context.Context) error {
+	return nil
+}
+
+func newSessionTestData() (*SessionHandler, *MockSessionRepository, uuid.UUID, *models.Session, *models.Session) {
+	repo := NewMockSessionRepository()
+	userID := uuid.New()
+
+	phone := &models.Session{ID: uuid.New(), UserID: userID, UserAgent: stringPtr("Pin/1.4 iOS")}
+	laptop := &models.Session{ID: uuid.New(), UserID: userID, UserAgent: stringPtr("Mozilla/5.0")}
+	stranger := &models.Session{ID: uuid.New(), UserID: uuid.New()}
+	for _, s := range []*models.Session{phone, laptop, stranger} {
+		repo.sessions[s.ID] = s
+	}
+
+	return NewSessionHandler(repo), repo, userID, phone, laptop
+}
+
+func withSession(req *http.Request, userID uuid.UUID, session *models.Session) *http.Request {
+	req = withUser(req, userID)
+	ctx := context.WithValue(req.Context(), middleware.SessionKey, session)
+	return req.WithContext(ctx)
+}
+
+func TestSessionHandler_ListSessions(t *testing.T) {
+	handler, _, userID, phone, _ := newSessionTestData()
+
+	req := withSession(httptest.NewRequest("GET", "/api/sessions", nil), userID, phone)
+	rr := httptest.NewRecorder()
+	handler.ListSessions(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ListSessions() status = %v, want %v", rr.Code, http.StatusOK)
+	}
+
+	var sessions []models.SessionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&sessions); err != nil {
+		t.Fatalf("ListSessions() failed to decode response: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("ListSessions() returned %d sessions, want 2", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Current != (s.ID == phone.ID) {
+			t.Errorf("session %s current = %v", s.ID, s.Current)
+		}
+	}
+}
+
+func TestSessionHandler_RevokeSession(t *testing.T) {
+	handler, repo, userID, phone, laptop := newSessionTestData()
+
+	revoke := func(id uuid.UUID) int {
+		req := withSession(withPathValue(httptest.NewRequest("DELETE", "/api/sessions/"+id.String(), nil), "id", id.String()), userID, phone)
+		rr := httptest.NewRecorder()
+		handler.RevokeSession(rr, req)
+		return rr.Code
+	}
+
+	if code := revoke(laptop.ID); code != http.StatusNoContent {
+		t.Errorf("RevokeSession() status = %v, want %v", code, http.StatusNoContent)
+	}
+	if _, ok := repo.sessions[laptop.ID]; ok {
+		t.Error("RevokeSession() left the session in place")
+	}
+
+	// someone else's session looks the same as one that doesn't exist
+	for id, s := range repo.sessions {
+		if s.UserID != userID {
+			if code := revoke(id); code != http.StatusNotFound {
+				t.Errorf("RevokeSession() on another user's session status = %v, want %v", code, http.StatusNotFound)
+			}
+		}
+	}
+}
+
+func TestSessionHandler_RevokeOtherSessions(t *testing.T) {
+	handler, repo, userID, phone, laptop := newSessionTestData()
+
+	req := withSession(httptest.NewRequest("DELETE", "/api/sessions", nil), userID, phone)
+	rr := httptest.NewRecorder()
+	handler.RevokeOtherSessions(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("RevokeOtherSessions() status = %v, want %v", rr.Code, http.StatusOK)
+	}
+	if _, ok := repo.sessions[phone.ID]; !ok {
+		t.Error("RevokeOtherSessions() revoked the current session")
+	}
+	if _, ok := repo.sessions[laptop.ID]; ok {
+		t.Error("RevokeOtherSessions() kept another session")
+	}
+	if len(repo.sessions) != 2 {
+		t.Error("RevokeOtherSessions() touched another user's sessions")
+	}
+}
+
+func TestSessionHandler_RevokeOtherSessions_NoSession(t *testing.T) {
+	handler, repo, userID, _, _ := newSessionTestData()
+	before := len(repo.sessions)
+
+	// a dev mode request is authenticated but has no session to keep
+	req := withUser(httptest.NewRequest("DELETE", "/api/sessions", nil), userID)
+	rr := httptest.NewRecorder()
+	handler.RevokeOtherSessions(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("RevokeOtherSessions() status = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+	if len(repo.sessions) != before {
+		t.Error("RevokeOtherSessions() revoked sessions without a current one")
+	}
+}
+
+func TestOAuthHandler_Refresh(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	sessionRepo := NewMockSessionRepository()
+	handler := NewOAuthHandler(NewMockOAuthRepository(), userRepo, sessionRepo, nil, nil)
+
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com"}
+	userRepo.users[user.ID] = user
+
+	session, err := handler.authMW.CreateSession(context.Background(), user.ID, middleware.ClientInfo{})
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	firstToken := session.RefreshToken
+
+	refresh := func(token string) (*httptest.ResponseRecorder, OAuthResponse) {
+		body, _ := json.Marshal(RefreshRequest{RefreshToken: token})
+		rr := httptest.NewRecorder()
+		handler.Refresh(rr, httptest.NewRequest("POST", "/api/auth/refresh", bytes.NewReader(body)))
+		var resp OAuthResponse
+		_ = json.NewDecoder(rr.Body).Decode(&resp)
+		return rr, resp
+	}
+
+	rr, rotated := refresh(firstToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Refresh() status = %v, want %v", rr.Code, http.StatusOK)
+	}
+	if rotated.RefreshToken == "" || rotated.RefreshToken == firstToken {
+		t.Error("Refresh() did not rotate the refresh token")
+	}
+	if !rotated.ExpiresAt.Before(rotated.RefreshExpiresAt) {
+		t.Error("Refresh() access token should expire before the refresh token")
+	}
+
+	// replaying the first token is treated as theft and kills the family,
+	// including the token we just got
+	if rr, _ := refresh(firstToken); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Refresh() with reused token status = %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+	if rr, _ := refresh(rotated.RefreshToken); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Refresh() after reuse status = %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
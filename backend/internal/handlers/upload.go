@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/metrics"
 	"github.com/pin-app/pin/internal/server"
 )
 
@@ -62,10 +63,12 @@ func (h *UploadHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
+	written, err := io.Copy(dst, file)
+	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save file"})
 		return
 	}
+	metrics.UploadBytes.Add(float64(written))
 
 	server.WriteJSON(w, http.StatusCreated, map[string]string{
 		"url": fmt.Sprintf("/uploads/%s", filename),
@@ -2,17 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
 	"github.com/pin-app/pin/internal/repository"
 	"github.com/pin-app/pin/internal/server"
 )
 
+// accountDeletionGrace is how long a deleted account can still be
+// recovered before it's erased
+const accountDeletionGrace = 30 * 24 * time.Hour
+
 type UserHandler struct {
 	userRepo  repository.UserRepository
 	validator *validator.Validate
@@ -58,14 +64,14 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/users/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
 	}
 
-	user, err := h.userRepo.GetByID(r.Context(), id)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	user, err := h.userRepo.GetVisibleByID(r.Context(), id, viewerID)
 	if err != nil {
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
@@ -75,13 +81,16 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/users/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
 	}
 
+	if _, ok := authorize(w, r, id, models.RoleAdmin); !ok {
+		return
+	}
+
 	var req models.UserUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
@@ -114,6 +123,9 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if req.PfpURL != nil {
 		user.PfpURL = req.PfpURL
 	}
+	if req.IsPrivate != nil {
+		user.IsPrivate = *req.IsPrivate
+	}
 	user.UpdatedAt = time.Now()
 
 	if err := h.userRepo.Update(r.Context(), user); err != nil {
@@ -125,21 +137,94 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/users/"):]
-	id, err := uuid.Parse(idStr)
+	id, err := server.PathUUID(r, "id")
 	if err != nil {
 		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 		return
 	}
 
-	if err := h.userRepo.Delete(r.Context(), id); err != nil {
+	userID, ok := authorize(w, r, id, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	scheduledAt, err := h.userRepo.ScheduleDeletion(r.Context(), id, userID, time.Now().Add(accountDeletionGrace))
+	if err != nil {
+		if errors.Is(err, repository.ErrLastAdmin) {
+			server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Cannot delete the last admin"})
+			return
+		}
 		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
+	server.WriteJSON(w, http.StatusAccepted, models.AccountDeletionResponse{DeletionScheduledAt: scheduledAt})
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion
+func (h *UserHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	userID, ok := authorize(w, r, id, models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.CancelDeletion(r.Context(), id, userID); err != nil {
+		if errors.Is(err, repository.ErrNoDeletionPending) {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "No deletion pending"})
+			return
+		}
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to cancel deletion"})
+		return
+	}
+
 	server.WriteJSON(w, http.StatusNoContent, nil)
 }
 
+func (h *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := server.PathUUID(r, "id")
+	if err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UserRoleUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := h.userRepo.UpdateRole(r.Context(), id, req.Role); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		case errors.Is(err, repository.ErrLastAdmin):
+			server.WriteJSON(w, http.StatusConflict, map[string]string{"error": "Cannot demote the last admin"})
+		default:
+			server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update role"})
+		}
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	server.WriteJSON(w, http.StatusOK, user.ToResponse())
+}
+
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
@@ -159,7 +244,8 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.userRepo.List(r.Context(), limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	users, err := h.userRepo.List(r.Context(), viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to list users"})
 		return
@@ -203,7 +289,8 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.userRepo.Search(r.Context(), query, limit, offset)
+	viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+	users, err := h.userRepo.Search(r.Context(), query, viewerID, limit, offset)
 	if err != nil {
 		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to search users"})
 		return
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
 	"github.com/pin-app/pin/internal/models"
 	"github.com/pin-app/pin/internal/repository"
 )
@@ -37,6 +39,10 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return user, nil
 }
 
+func (m *MockUserRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.User, error) {
+	return m.GetByID(ctx, id)
+}
+
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	for _, user := range m.users {
 		if user.Email == email {
@@ -63,15 +69,93 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) erro
 	return nil
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.UserRole) error {
+	user, exists := m.users[id]
+	if !exists {
+		return repository.ErrUserNotFound
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		admins := 0
+		for _, u := range m.users {
+			if u.Role == models.RoleAdmin {
+				admins++
+			}
+		}
+		if admins <= 1 {
+			return repository.ErrLastAdmin
+		}
+	}
+	user.Role = role
+	return nil
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
 	if _, exists := m.users[id]; !exists {
 		return repository.ErrUserNotFound
 	}
+	if actor, ok := m.users[actorID]; id != actorID && (!ok || actor.Role != models.RoleAdmin) {
+		return repository.ErrUserNotFound
+	}
 	delete(m.users, id)
 	return nil
 }
 
-func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (m *MockUserRepository) ScheduleDeletion(ctx context.Context, id, actorID uuid.UUID, at time.Time) (time.Time, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return time.Time{}, repository.ErrUserNotFound
+	}
+	if actor, ok := m.users[actorID]; id != actorID && (!ok || actor.Role != models.RoleAdmin) {
+		return time.Time{}, repository.ErrUserNotFound
+	}
+	if user.Role == models.RoleAdmin {
+		others := 0
+		for _, u := range m.users {
+			if u.ID != id && u.Role == models.RoleAdmin && u.DeletionScheduledAt == nil {
+				others++
+			}
+		}
+		if others == 0 {
+			return time.Time{}, repository.ErrLastAdmin
+		}
+	}
+	if user.DeletionScheduledAt == nil {
+		user.DeletionScheduledAt = &at
+	}
+	return *user.DeletionScheduledAt, nil
+}
+
+func (m *MockUserRepository) CancelDeletion(ctx context.Context, id, actorID uuid.UUID) error {
+	user, exists := m.users[id]
+	if !exists || user.DeletionScheduledAt == nil {
+		return repository.ErrNoDeletionPending
+	}
+	if actor, ok := m.users[actorID]; id != actorID && (!ok || actor.Role != models.RoleAdmin) {
+		return repository.ErrNoDeletionPending
+	}
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+func (m *MockUserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, user := range m.users {
+		if user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(now) && len(ids) < limit {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MockUserRepository) Erase(ctx context.Context, id uuid.UUID) ([]string, error) {
+	if _, exists := m.users[id]; !exists {
+		return nil, repository.ErrNoDeletionPending
+	}
+	delete(m.users, id)
+	return nil, nil
+}
+
+func (m *MockUserRepository) List(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	var users []*models.User
 	count := 0
 	for _, user := range m.users {
@@ -83,7 +167,7 @@ func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*mo
 	return users, nil
 }
 
-func (m *MockUserRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error) {
+func (m *MockUserRepository) Search(ctx context.Context, query string, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	var users []*models.User
 	count := 0
 	for _, user := range m.users {
@@ -153,7 +237,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 	}
 	mockRepo.Create(context.Background(), user)
 
-	req := httptest.NewRequest("GET", "/api/users/"+userID.String(), nil)
+	req := withPathValue(httptest.NewRequest("GET", "/api/users/"+userID.String(), nil), "id", userID.String())
 	rr := httptest.NewRecorder()
 	handler.GetUser(rr, req)
 
@@ -176,7 +260,7 @@ func TestUserHandler_GetUser_NotFound(t *testing.T) {
 	handler := NewUserHandler(mockRepo)
 
 	nonExistentID := uuid.New()
-	req := httptest.NewRequest("GET", "/api/users/"+nonExistentID.String(), nil)
+	req := withPathValue(httptest.NewRequest("GET", "/api/users/"+nonExistentID.String(), nil), "id", nonExistentID.String())
 	rr := httptest.NewRecorder()
 	handler.GetUser(rr, req)
 
@@ -208,8 +292,9 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("PUT", "/api/users/"+userID.String(), bytes.NewBuffer(jsonBody))
+	req := withPathValue(httptest.NewRequest("PUT", "/api/users/"+userID.String(), bytes.NewBuffer(jsonBody)), "id", userID.String())
 	req.Header.Set("Content-Type", "application/json")
+	req = withUser(req, userID)
 
 	rr := httptest.NewRecorder()
 	handler.UpdateUser(rr, req)
@@ -231,12 +316,146 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 	}
 	mockRepo.Create(context.Background(), user)
 
-	req := httptest.NewRequest("DELETE", "/api/users/"+userID.String(), nil)
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+userID.String(), nil), "id", userID.String())
+	req = withUser(req, userID)
 	rr := httptest.NewRecorder()
 	handler.DeleteUser(rr, req)
 
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("DeleteUser() status = %v, want %v", rr.Code, http.StatusAccepted)
+	}
+
+	var resp models.AccountDeletionResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.DeletionScheduledAt.Before(time.Now().Add(accountDeletionGrace - time.Minute)) {
+		t.Errorf("DeleteUser() scheduled deletion at %v, want after the grace period", resp.DeletionScheduledAt)
+	}
+	if _, err := mockRepo.GetByID(context.Background(), userID); err != nil {
+		t.Errorf("DeleteUser() removed the user before the grace period ended")
+	}
+}
+
+func TestUserHandler_CancelDeletion(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	userID := uuid.New()
+	scheduled := time.Now().Add(time.Hour)
+	mockRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com", DeletionScheduledAt: &scheduled})
+
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+userID.String()+"/deletion", nil), "id", userID.String())
+	req = withUser(req, userID)
+	rr := httptest.NewRecorder()
+	handler.CancelDeletion(rr, req)
+
 	if rr.Code != http.StatusNoContent {
-		t.Errorf("DeleteUser() status = %v, want %v", rr.Code, http.StatusNoContent)
+		t.Errorf("CancelDeletion() status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if mockRepo.users[userID].DeletionScheduledAt != nil {
+		t.Errorf("CancelDeletion() left the deletion scheduled")
+	}
+
+	// nothing left to cancel
+	rr = httptest.NewRecorder()
+	handler.CancelDeletion(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("CancelDeletion() status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestUserHandler_UpdateUser_Forbidden(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	userID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com"})
+
+	jsonBody, _ := json.Marshal(models.UserUpdateRequest{Bio: stringPtr("Hijacked bio")})
+	req := withPathValue(httptest.NewRequest("PUT", "/api/users/"+userID.String(), bytes.NewBuffer(jsonBody)), "id", userID.String())
+	req = withUser(req, uuid.New())
+
+	rr := httptest.NewRecorder()
+	handler.UpdateUser(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("UpdateUser() status = %v, want %v", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestUserHandler_DeleteUser_Forbidden(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	userID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com"})
+
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+userID.String(), nil), "id", userID.String())
+	req = withUser(req, uuid.New())
+
+	rr := httptest.NewRecorder()
+	handler.DeleteUser(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("DeleteUser() status = %v, want %v", rr.Code, http.StatusForbidden)
+	}
+	if _, err := mockRepo.GetByID(context.Background(), userID); err != nil {
+		t.Errorf("DeleteUser() removed a user the caller does not own")
+	}
+}
+
+func TestUserHandler_DeleteUser_AdminOverride(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	userID := uuid.New()
+	adminID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com"})
+	mockRepo.Create(context.Background(), &models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin})
+
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+userID.String(), nil), "id", userID.String())
+	req = withUser(req, adminID)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RoleKey, models.RoleAdmin))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteUser(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("DeleteUser() status = %v, want %v", rr.Code, http.StatusAccepted)
+	}
+}
+
+func TestUserHandler_UpdateUserRole(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	userID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: userID, Email: "test@example.com", Role: models.RoleUser})
+
+	jsonBody, _ := json.Marshal(models.UserRoleUpdateRequest{Role: models.RoleModerator})
+	req := withPathValue(httptest.NewRequest("PUT", "/api/users/"+userID.String()+"/role", bytes.NewBuffer(jsonBody)), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	handler.UpdateUserRole(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("UpdateUserRole() status = %v, want %v", rr.Code, http.StatusOK)
+	}
+	if mockRepo.users[userID].Role != models.RoleModerator {
+		t.Errorf("UpdateUserRole() role = %v, want %v", mockRepo.users[userID].Role, models.RoleModerator)
+	}
+}
+
+func TestUserHandler_DeleteUser_Unauthenticated(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	id := uuid.New()
+	req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+id.String(), nil), "id", id.String())
+	rr := httptest.NewRecorder()
+	handler.DeleteUser(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("DeleteUser() status = %v, want %v", rr.Code, http.StatusUnauthorized)
 	}
 }
 
@@ -266,7 +485,84 @@ func TestUserHandler_SearchUsers(t *testing.T) {
 	}
 }
 
+// withUser attaches an authenticated user ID the same way AuthMiddleware does
+func withUser(req *http.Request, userID uuid.UUID) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+}
+
+// withPathValue sets a path parameter the way the router would
+func withPathValue(req *http.Request, name, value string) *http.Request {
+	req.SetPathValue(name, value)
+	return req
+}
+
 // Helper function to create string pointers
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestUserHandler_UpdateUserRole_LastAdmin(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	adminID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin})
+
+	demote := func(id uuid.UUID) int {
+		jsonBody, _ := json.Marshal(models.UserRoleUpdateRequest{Role: models.RoleUser})
+		req := withPathValue(httptest.NewRequest("PUT", "/api/users/"+id.String()+"/role", bytes.NewBuffer(jsonBody)), "id", id.String())
+		rr := httptest.NewRecorder()
+		handler.UpdateUserRole(rr, req)
+		return rr.Code
+	}
+
+	if code := demote(adminID); code != http.StatusConflict {
+		t.Errorf("UpdateUserRole() demoting the last admin status = %v, want %v", code, http.StatusConflict)
+	}
+	if mockRepo.users[adminID].Role != models.RoleAdmin {
+		t.Errorf("UpdateUserRole() demoted the last admin")
+	}
+
+	// with a second admin around it's allowed
+	mockRepo.Create(context.Background(), &models.User{ID: uuid.New(), Email: "admin2@example.com", Role: models.RoleAdmin})
+	if code := demote(adminID); code != http.StatusOK {
+		t.Errorf("UpdateUserRole() status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := demote(uuid.New()); code != http.StatusNotFound {
+		t.Errorf("UpdateUserRole() on a missing user status = %v, want %v", code, http.StatusNotFound)
+	}
+}
+
+func TestUserHandler_DeleteUser_LastAdmin(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	handler := NewUserHandler(mockRepo)
+
+	adminID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin})
+
+	deleteUser := func(id uuid.UUID) int {
+		req := withPathValue(httptest.NewRequest("DELETE", "/api/users/"+id.String(), nil), "id", id.String())
+		req = withUser(req, id)
+		rr := httptest.NewRecorder()
+		handler.DeleteUser(rr, req)
+		return rr.Code
+	}
+
+	if code := deleteUser(adminID); code != http.StatusConflict {
+		t.Errorf("DeleteUser() on the last admin status = %v, want %v", code, http.StatusConflict)
+	}
+	if mockRepo.users[adminID].DeletionScheduledAt != nil {
+		t.Errorf("DeleteUser() scheduled the last admin for deletion")
+	}
+
+	// with a second admin around it's allowed, but then that one has to stay
+	otherID := uuid.New()
+	mockRepo.Create(context.Background(), &models.User{ID: otherID, Email: "admin2@example.com", Role: models.RoleAdmin})
+	if code := deleteUser(adminID); code != http.StatusAccepted {
+		t.Errorf("DeleteUser() status = %v, want %v", code, http.StatusAccepted)
+	}
+	if code := deleteUser(otherID); code != http.StatusConflict {
+		t.Errorf("DeleteUser() on the last admin not being deleted status = %v, want %v", code, http.StatusConflict)
+	}
+}
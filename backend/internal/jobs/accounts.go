@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// accounts erased per run; anything left over waits for the next one
+const eraseBatchSize = 50
+
+// eraseDueAccounts erases accounts whose deletion grace period is over and
+// removes the files they uploaded and their data exports
+func eraseDueAccounts(ctx context.Context, userRepo repository.UserRepository, exportRepo repository.DataExportRepository, uploadDir, exportDir string) error {
+	ids, err := userRepo.ListDueForDeletion(ctx, time.Now(), eraseBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range ids {
+		// the rows go with the user, so find the archives first
+		exports, err := exportRepo.ListByUserID(ctx, id, 100, 0)
+		if err != nil {
+			failed++
+			slog.Error("failed to list data exports of erased account", "error", err, "user_id", id)
+			continue
+		}
+
+		urls, err := userRepo.Erase(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNoDeletionPending) {
+				continue
+			}
+			if errors.Is(err, repository.ErrLastAdmin) {
+				slog.Warn("keeping the last admin's account", "user_id", id)
+				continue
+			}
+			failed++
+			slog.Error("failed to erase account", "error", err, "user_id", id)
+			continue
+		}
+
+		for _, u := range urls {
+			removeUpload(uploadDir, u)
+		}
+		for _, export := range exports {
+			removeFile(filepath.Join(exportDir, export.FileName()))
+		}
+		slog.Info("erased account", "user_id", id, "files", len(urls))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to erase %d of %d accounts", failed, len(ids))
+	}
+	return nil
+}
+
+// removeUpload deletes the file behind a /uploads/ URL. URLs pointing
+// anywhere else, like a provider's profile picture, are left alone.
+func removeUpload(uploadDir, rawURL string) {
+	file, ok := uploadPath(uploadDir, rawURL)
+	if !ok {
+		return
+	}
+
+	removeFile(file)
+}
+
+func removeFile(file string) {
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to remove file", "error", err, "file", file)
+	}
+}
+
+// uploadPath maps a /uploads/ URL, relative or not, to its file in
+// uploadDir
+func uploadPath(uploadDir, rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	// uploads are stored flat, so anything else isn't ours
+	p := path.Clean(u.Path)
+	if path.Dir(p) != "/uploads" {
+		return "", false
+	}
+
+	return filepath.Join(uploadDir, path.Base(p)), true
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// fakeUserRepo only implements what the erase job calls
+type fakeUserRepo struct {
+	repository.UserRepository
+	due    []uuid.UUID
+	urls   map[uuid.UUID][]string
+	admins map[uuid.UUID]bool
+	erased []uuid.UUID
+}
+
+func (r *fakeUserRepo) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	return r.due, nil
+}
+
+func (r *fakeUserRepo) Erase(ctx context.Context, id uuid.UUID) ([]string, error) {
+	if r.admins[id] {
+		return nil, repository.ErrLastAdmin
+	}
+	urls, ok := r.urls[id]
+	if !ok {
+		return nil, repository.ErrNoDeletionPending
+	}
+	r.erased = append(r.erased, id)
+	return urls, nil
+}
+
+type fakeExportRepo struct {
+	repository.DataExportRepository
+	exports map[uuid.UUID][]*models.DataExport
+}
+
+func (r *fakeExportRepo) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.DataExport, error) {
+	return r.exports[userID], nil
+}
+
+func TestEraseDueAccounts(t *testing.T) {
+	dir := t.TempDir()
+	exportDir := t.TempDir()
+	for _, name := range []string{"mine.jpg", "avatar.png", "keep.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	erased, cancelled, lastAdmin := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeUserRepo{
+		due:    []uuid.UUID{erased, cancelled, lastAdmin},
+		admins: map[uuid.UUID]bool{lastAdmin: true},
+		urls: map[uuid.UUID][]string{
+			erased: {
+				"/uploads/mine.jpg",
+				"https://api.pin.app/uploads/avatar.png",
+				"https://lh3.googleusercontent.com/a/photo.jpg",
+				"/uploads/../keep.jpg",
+				"/uploads/missing.jpg",
+			},
+		},
+	}
+
+	export := &models.DataExport{ID: uuid.New(), UserID: erased}
+	if err := os.WriteFile(filepath.Join(exportDir, export.FileName()), []byte("PK"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	exportRepo := &fakeExportRepo{exports: map[uuid.UUID][]*models.DataExport{erased: {export}}}
+
+	if err := eraseDueAccounts(context.Background(), repo, exportRepo, dir, exportDir); err != nil {
+		t.Fatalf("eraseDueAccounts() error = %v", err)
+	}
+
+	if len(repo.erased) != 1 || repo.erased[0] != erased {
+		t.Errorf("erased %v, want only %v", repo.erased, erased)
+	}
+	for name, want := range map[string]bool{"mine.jpg": false, "avatar.png": false, "keep.jpg": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(exportDir, export.FileName())); err == nil {
+		t.Error("data export archive survived the account")
+	}
+}
@@ -0,0 +1,351 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+const (
+	// DataExportTTL is how long a finished archive can be downloaded
+	DataExportTTL = 7 * 24 * time.Hour
+	// exports running longer than this are assumed to have died with
+	// their instance and are started over
+	dataExportStaleAfter = time.Hour
+	// exports built per run
+	dataExportBatchSize = 5
+	exportPageSize      = 100
+)
+
+// ExportBuilder writes a user's data as a zip, reading it through the same
+// repository methods the API uses
+type ExportBuilder struct {
+	users         repository.UserRepository
+	posts         repository.PostRepository
+	comments      repository.CommentRepository
+	ratings       repository.RatingRepository
+	follows       repository.FollowRepository
+	notifications repository.NotificationRepository
+	uploadDir     string
+}
+
+func NewExportBuilder(
+	users repository.UserRepository,
+	posts repository.PostRepository,
+	comments repository.CommentRepository,
+	ratings repository.RatingRepository,
+	follows repository.FollowRepository,
+	notifications repository.NotificationRepository,
+	uploadDir string,
+) *ExportBuilder {
+	return &ExportBuilder{
+		users:         users,
+		posts:         posts,
+		comments:      comments,
+		ratings:       ratings,
+		follows:       follows,
+		notifications: notifications,
+		uploadDir:     uploadDir,
+	}
+}
+
+type exportPost struct {
+	*models.Post
+	Images []exportImage `json:"images"`
+}
+
+type exportImage struct {
+	*models.PostImage
+	// File is the image's path inside the archive, when it was uploaded here
+	File string `json:"file,omitempty"`
+}
+
+// Write builds the archive for userID into w
+func (b *ExportBuilder) Write(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	user, err := b.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "profile.json", user); err != nil {
+		return err
+	}
+
+	posts, err := collect(func(limit, offset int) ([]*models.Post, error) {
+		return b.posts.ListByUserID(ctx, userID, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	exported := make([]exportPost, len(posts))
+	for i, post := range posts {
+		images, err := b.posts.GetImagesByPostID(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		exported[i] = exportPost{Post: post, Images: make([]exportImage, len(images))}
+		for j, image := range images {
+			exported[i].Images[j] = exportImage{PostImage: image}
+			if file, ok := uploadPath(b.uploadDir, image.ImageURL); ok {
+				name := "images/" + filepath.Base(file)
+				if err := copyFile(zw, name, file); err != nil {
+					slog.Warn("skipping image missing from export", "error", err, "url", image.ImageURL)
+					continue
+				}
+				exported[i].Images[j].File = name
+			}
+		}
+	}
+	if err := writeJSON(zw, "posts.json", exported); err != nil {
+		return err
+	}
+
+	comments, err := collect(func(limit, offset int) ([]*models.Comment, error) {
+		return b.comments.ListByUserID(ctx, userID, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "comments.json", comments); err != nil {
+		return err
+	}
+
+	ratings, err := collect(func(limit, offset int) ([]*models.PlaceRating, error) {
+		return b.ratings.GetRatingsByUserID(ctx, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "ratings.json", ratings); err != nil {
+		return err
+	}
+
+	comparisons, err := collect(func(limit, offset int) ([]*models.PlaceComparison, error) {
+		return b.ratings.GetComparisonsByUserID(ctx, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "comparisons.json", comparisons); err != nil {
+		return err
+	}
+
+	following, err := collect(func(limit, offset int) ([]*models.User, error) {
+		return b.follows.ListFollowing(ctx, userID, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "following.json", userResponses(following)); err != nil {
+		return err
+	}
+
+	followers, err := collect(func(limit, offset int) ([]*models.User, error) {
+		return b.follows.ListFollowers(ctx, userID, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "followers.json", userResponses(followers)); err != nil {
+		return err
+	}
+
+	notifications, err := collect(func(limit, offset int) ([]*models.Notification, error) {
+		return b.notifications.ListByUserID(ctx, userID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "notifications.json", notifications); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// collect pages through a ListBy* method until it runs out
+func collect[T any](list func(limit, offset int) ([]T, error)) ([]T, error) {
+	all := []T{}
+	for offset := 0; ; offset += exportPageSize {
+		page, err := list(exportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < exportPageSize {
+			return all, nil
+		}
+	}
+}
+
+// other people in the export get their public profile only
+func userResponses(users []*models.User) []models.UserResponse {
+	responses := make([]models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+		responses[i].Email = ""
+	}
+	return responses
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func copyFile(zw *zip.Writer, name, file string) error {
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// images are already compressed
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// buildPendingExports builds queued exports and tells their owners
+func buildPendingExports(ctx context.Context, exportRepo repository.DataExportRepository, notificationRepo repository.NotificationRepository, builder *ExportBuilder, exportDir string) error {
+	for range dataExportBatchSize {
+		export, err := exportRepo.ClaimPending(ctx, time.Now().Add(-dataExportStaleAfter))
+		if err != nil {
+			if errors.Is(err, repository.ErrDataExportNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		size, err := writeExport(ctx, builder, export, exportDir)
+		if err != nil {
+			slog.Error("failed to build data export", "error", err, "export_id", export.ID, "user_id", export.UserID)
+			if err := exportRepo.MarkFailed(ctx, export.ID, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := exportRepo.MarkReady(ctx, export.ID, size, time.Now().Add(DataExportTTL)); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = notificationRepo.Create(ctx, &models.Notification{
+			ID:        uuid.New(),
+			UserID:    export.UserID,
+			ActorID:   export.UserID,
+			Type:      models.NotificationTypeDataExport,
+			Data:      map[string]string{"export_id": export.ID.String()},
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			// the export is still listed, so don't fail it over this
+			slog.Error("failed to notify user of data export", "error", err, "export_id", export.ID)
+		}
+
+		slog.Info("built data export", "export_id", export.ID, "user_id", export.UserID, "bytes", size)
+	}
+	return nil
+}
+
+// writeExport builds into a temporary file and renames it into place, so a
+// half written archive is never served
+func writeExport(ctx context.Context, builder *ExportBuilder, export *models.DataExport, exportDir string) (int64, error) {
+	tmp, err := os.CreateTemp(exportDir, "export-*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := builder.Write(ctx, export.UserID, tmp); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(exportDir, export.FileName())); err != nil {
+		return 0, fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// expireExports removes archives whose download link has run out
+func expireExports(ctx context.Context, exportRepo repository.DataExportRepository, exportDir string) error {
+	expired, err := exportRepo.ExpireReady(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, export := range expired {
+		removeFile(filepath.Join(exportDir, export.FileName()))
+	}
+	return nil
+}
+
+// RegisterExports adds the jobs that build and expire data exports
+func RegisterExports(s *Scheduler, db *database.DB, uploadDir, exportDir string) {
+	exportRepo := repository.NewDataExportRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	builder := NewExportBuilder(
+		repository.NewUserRepository(db),
+		repository.NewPostRepository(db),
+		repository.NewCommentRepository(db),
+		repository.NewRatingRepository(db),
+		repository.NewFollowRepository(db),
+		notificationRepo,
+		uploadDir,
+	)
+
+	s.Register(Job{
+		Name:     "data_exports.build",
+		Interval: time.Minute,
+		Jitter:   10 * time.Second,
+		Timeout:  30 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return buildPendingExports(ctx, exportRepo, notificationRepo, builder, exportDir)
+		},
+	})
+
+	s.Register(Job{
+		Name:     "data_exports.expire",
+		Interval: time.Hour,
+		Jitter:   5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return expireExports(ctx, exportRepo, exportDir)
+		},
+	})
+}
@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// the fakes below only implement what the export builder reads
+
+type exportUsers struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r exportUsers) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.user, nil
+}
+
+type exportPosts struct {
+	repository.PostRepository
+	posts  []*models.Post
+	images map[uuid.UUID][]*models.PostImage
+}
+
+func (r exportPosts) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+	return page(r.posts, limit, offset), nil
+}
+
+func (r exportPosts) GetImagesByPostID(ctx context.Context, postID uuid.UUID) ([]*models.PostImage, error) {
+	return r.images[postID], nil
+}
+
+// exportComments hides comments on hidden posts from everyone but their
+// author, like the comments repository
+type exportComments struct {
+	repository.CommentRepository
+	comments []*models.Comment
+	hidden   map[uuid.UUID]bool
+}
+
+func (r exportComments) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	var visible []*models.Comment
+	for _, comment := range r.comments {
+		if comment.UserID == viewerID || !r.hidden[comment.PostID] {
+			visible = append(visible, comment)
+		}
+	}
+	return page(visible, limit, offset), nil
+}
+
+type exportRatings struct{ repository.RatingRepository }
+
+func (exportRatings) GetRatingsByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.PlaceRating, error) {
+	return nil, nil
+}
+
+func (exportRatings) GetComparisonsByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.PlaceComparison, error) {
+	return nil, nil
+}
+
+type exportFollows struct {
+	repository.FollowRepository
+	followers []*models.User
+}
+
+func (exportFollows) ListFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	return nil, nil
+}
+
+func (r exportFollows) ListFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	return page(r.followers, limit, offset), nil
+}
+
+type exportNotifications struct {
+	repository.NotificationRepository
+}
+
+func (exportNotifications) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Notification, error) {
+	return nil, nil
+}
+
+func page[T any](all []T, limit, offset int) []T {
+	if offset >= len(all) {
+		return nil
+	}
+	return all[offset:min(offset+limit, len(all))]
+}
+
+func TestExportBuilderWrite(t *testing.T) {
+	uploadDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(uploadDir, "photo.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{ID: uuid.New(), Email: "me@example.com"}
+
+	// more posts than fit in a page, to check paging
+	var posts []*models.Post
+	for range exportPageSize + 1 {
+		posts = append(posts, &models.Post{ID: uuid.New(), UserID: user.ID})
+	}
+	images := map[uuid.UUID][]*models.PostImage{
+		posts[0].ID: {
+			{ID: uuid.New(), PostID: posts[0].ID, ImageURL: "/uploads/photo.jpg"},
+			{ID: uuid.New(), PostID: posts[0].ID, ImageURL: "https://example.com/elsewhere.jpg"},
+		},
+	}
+	followers := []*models.User{{ID: uuid.New(), Email: "friend@example.com"}}
+
+	// a comment on someone else's post the user can no longer see
+	hiddenPost := uuid.New()
+	comments := []*models.Comment{{ID: uuid.New(), PostID: hiddenPost, UserID: user.ID, Content: "before they blocked me"}}
+
+	builder := NewExportBuilder(
+		exportUsers{user: user},
+		exportPosts{posts: posts, images: images},
+		exportComments{comments: comments, hidden: map[uuid.UUID]bool{hiddenPost: true}},
+		exportRatings{},
+		exportFollows{followers: followers},
+		exportNotifications{},
+		uploadDir,
+	)
+
+	var buf bytes.Buffer
+	if err := builder.Write(context.Background(), user.ID, &buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	for _, name := range []string{"profile.json", "posts.json", "comments.json", "ratings.json", "comparisons.json", "following.json", "followers.json", "notifications.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	if string(files["images/photo.jpg"]) != "jpeg" {
+		t.Errorf("images/photo.jpg = %q, want the uploaded file", files["images/photo.jpg"])
+	}
+
+	var exported []struct {
+		ID     uuid.UUID `json:"id"`
+		Images []struct {
+			File string `json:"file"`
+		} `json:"images"`
+	}
+	json.Unmarshal(files["posts.json"], &exported)
+	if len(exported) != len(posts) {
+		t.Fatalf("posts.json has %d posts, want %d", len(exported), len(posts))
+	}
+	if exported[0].Images[0].File != "images/photo.jpg" || exported[0].Images[1].File != "" {
+		t.Errorf("posts.json images = %+v", exported[0].Images)
+	}
+
+	var exportedComments []*models.Comment
+	json.Unmarshal(files["comments.json"], &exportedComments)
+	if len(exportedComments) != 1 || exportedComments[0].ID != comments[0].ID {
+		t.Errorf("comments.json = %s, want the comment on the hidden post", files["comments.json"])
+	}
+
+	if bytes.Contains(files["followers.json"], []byte("friend@example.com")) {
+		t.Error("followers.json leaks other users' email addresses")
+	}
+}
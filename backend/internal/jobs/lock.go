@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"time"
+)
+
+// Locker hands out named locks shared by every instance
+type Locker interface {
+	// TryLock takes the named lock if nobody holds it. ok is false when
+	// it's held elsewhere; otherwise unlock must be called once done.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// AdvisoryLocker uses Postgres session advisory locks. Each held lock pins
+// a connection, and a crashed instance's locks go away with its connections.
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	key := lockKey(name)
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// the job's context may already be cancelled by now
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			slog.Error("failed to release advisory lock, dropping connection", "error", err, "lock", name)
+			// closing the session releases the lock, so don't pool it
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return unlock, true, nil
+}
+
+// lockKey maps a job name to a stable advisory lock key
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("pin:jobs:" + name))
+	return int64(h.Sum64())
+}
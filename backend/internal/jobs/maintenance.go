@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// keep magic links as long as the email sign in limit counts them
+const magicLinkRetention = time.Hour
+
+// DefaultSoftDeleteRetention is how long soft-deleted rows stay around
+// before they're purged for good
+const DefaultSoftDeleteRetention = 30 * 24 * time.Hour
+
+// RegisterMaintenance adds the cleanup, purge and account deletion jobs
+func RegisterMaintenance(s *Scheduler, db *database.DB, uploadDir, exportDir string, softDeleteRetention time.Duration) {
+	userRepo := repository.NewUserRepository(db)
+	exportRepo := repository.NewDataExportRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	// cleanup never reads provider tokens, so no keyring
+	oauthRepo := repository.NewOAuthRepository(db, nil)
+	magicLinkRepo := repository.NewMagicLinkRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+
+	s.Register(Job{
+		Name:     "sessions.cleanup",
+		Interval: time.Hour,
+		Jitter:   5 * time.Minute,
+		Run:      sessionRepo.CleanupExpired,
+	})
+
+	// also removes expired exchange codes
+	s.Register(Job{
+		Name:     "oauth_states.cleanup",
+		Interval: 15 * time.Minute,
+		Jitter:   time.Minute,
+		Run:      oauthRepo.CleanupExpiredStates,
+	})
+
+	s.Register(Job{
+		Name:     "magic_links.cleanup",
+		Interval: time.Hour,
+		Jitter:   5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return magicLinkRepo.CleanupExpired(ctx, time.Now().Add(-magicLinkRetention))
+		},
+	})
+
+	s.Register(Job{
+		Name:     "accounts.erase",
+		Interval: time.Hour,
+		Jitter:   5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return eraseDueAccounts(ctx, userRepo, exportRepo, uploadDir, exportDir)
+		},
+	})
+
+	s.Register(Job{
+		Name:     "soft_deleted.purge",
+		Interval: 24 * time.Hour,
+		Jitter:   30 * time.Minute,
+		Timeout:  time.Hour,
+		Run: func(ctx context.Context) error {
+			return purgeSoftDeleted(ctx, maintenanceRepo, uploadDir, time.Now().Add(-softDeleteRetention))
+		},
+	})
+}
+
+// purgeSoftDeleted hard-deletes rows soft-deleted before the cutoff and
+// removes the uploads only they used
+func purgeSoftDeleted(ctx context.Context, maintenanceRepo repository.MaintenanceRepository, uploadDir string, before time.Time) error {
+	purged, urls, err := maintenanceRepo.PurgeSoftDeleted(ctx, before)
+	if err != nil {
+		return err
+	}
+	for table, n := range purged {
+		if n > 0 {
+			slog.Info("purged soft-deleted rows", "table", table, "rows", n)
+		}
+	}
+	for _, u := range urls {
+		removeUpload(uploadDir, u)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pin-app/pin/internal/repository"
+)
+
+type fakeMaintenanceRepo struct {
+	repository.MaintenanceRepository
+	urls []string
+}
+
+func (r *fakeMaintenanceRepo) PurgeSoftDeleted(ctx context.Context, before time.Time) (map[string]int64, []string, error) {
+	return map[string]int64{"posts": 1, "post_images": int64(len(r.urls))}, r.urls, nil
+}
+
+func TestPurgeSoftDeleted(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"purged.jpg", "keep.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &fakeMaintenanceRepo{urls: []string{
+		"/uploads/purged.jpg",
+		"https://example.com/elsewhere.jpg",
+		"/uploads/../keep.jpg",
+	}}
+	if err := purgeSoftDeleted(context.Background(), repo, dir, time.Now()); err != nil {
+		t.Fatalf("purgeSoftDeleted() error = %v", err)
+	}
+
+	for name, want := range map[string]bool{"purged.jpg": false, "keep.jpg": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
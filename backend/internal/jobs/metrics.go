@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"github.com/pin-app/pin/internal/metrics"
+)
+
+// RegisterMetrics reports each job's Stats on every scrape
+func RegisterMetrics(s *Scheduler) {
+	perJob := func(read func(Stats) float64) func() []metrics.Sample {
+		return func() []metrics.Sample {
+			stats := s.Stats()
+			samples := make([]metrics.Sample, len(stats))
+			for i, st := range stats {
+				samples[i] = metrics.Sample{Labels: []string{st.Name}, Value: read(st)}
+			}
+			return samples
+		}
+	}
+	job := []string{"job"}
+
+	metrics.Default.NewCounterFunc("pin_job_runs_total", "Job runs, including failed ones.", job,
+		perJob(func(st Stats) float64 { return float64(st.Runs) }))
+	metrics.Default.NewCounterFunc("pin_job_failures_total", "Job runs that returned an error.", job,
+		perJob(func(st Stats) float64 { return float64(st.Failures) }))
+	metrics.Default.NewCounterFunc("pin_job_skipped_total", "Job runs skipped because another instance held the lock.", job,
+		perJob(func(st Stats) float64 { return float64(st.Skipped) }))
+	metrics.Default.NewGaugeFunc("pin_job_running", "Whether the job is running right now.", job,
+		perJob(func(st Stats) float64 {
+			if st.Running {
+				return 1
+			}
+			return 0
+		}))
+	metrics.Default.NewGaugeFunc("pin_job_last_duration_seconds", "How long the job's last run took.", job,
+		perJob(func(st Stats) float64 { return st.LastDuration.Seconds() }))
+	metrics.Default.NewGaugeFunc("pin_job_last_success_timestamp_seconds", "When the job last succeeded, as a Unix time; 0 if it hasn't yet.", job,
+		perJob(func(st Stats) float64 {
+			if st.LastSuccess.IsZero() {
+				return 0
+			}
+			return float64(st.LastSuccess.Unix())
+		}))
+}
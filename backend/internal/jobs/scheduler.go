@@ -0,0 +1,209 @@
+// Package jobs runs periodic background work inside the server process.
+// Every run takes a lock first, so with several instances sharing a
+// database each job still runs one at a time.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Job is work repeated on an interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Jitter adds up to this much random delay before each run, so
+	// instances started together don't all hit the database at once
+	Jitter time.Duration
+	// Timeout bounds a single run (default: Interval)
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Stats describes what a job has done since the server started
+type Stats struct {
+	Name     string
+	Runs     int64
+	Failures int64
+	// Skipped counts runs another instance already had the lock for
+	Skipped      int64
+	Running      bool
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastSuccess  time.Time
+	LastError    string
+}
+
+type entry struct {
+	job   Job
+	stats Stats
+}
+
+// Scheduler runs registered jobs until stopped
+type Scheduler struct {
+	locker Locker
+
+	mu      sync.Mutex
+	entries []*entry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New returns a scheduler that takes locks from locker; nil runs jobs
+// without one, which is only safe with a single instance
+func New(locker Locker) *Scheduler {
+	return &Scheduler{locker: locker}
+}
+
+// Register adds a job. Jobs registered after Start aren't run.
+func (s *Scheduler) Register(job Job) {
+	if job.Name == "" || job.Interval <= 0 || job.Run == nil {
+		panic(fmt.Sprintf("jobs: invalid job %q", job.Name))
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = job.Interval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, &entry{job: job, stats: Stats{Name: job.Name}})
+}
+
+// Start runs each job after its jitter and then on its interval
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+	slog.Info("job scheduler started", "jobs", len(s.entries))
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+}
+
+// Stats returns a snapshot of every job's stats
+func (s *Scheduler) Stats() []Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]Stats, len(s.entries))
+	for i, e := range s.entries {
+		stats[i] = e.stats
+	}
+	return stats
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	delay := jitter(e.job.Jitter)
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, e)
+		delay = e.job.Interval + jitter(e.job.Jitter)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	log := slog.With("job", e.job.Name)
+
+	if s.locker != nil {
+		unlock, ok, err := s.locker.TryLock(ctx, e.job.Name)
+		if err != nil {
+			log.Error("failed to take job lock", "error", err)
+			s.record(e, func(st *Stats) {
+				st.Failures++
+				st.LastError = err.Error()
+			})
+			return
+		}
+		if !ok {
+			log.Debug("job is running elsewhere, skipping")
+			s.record(e, func(st *Stats) { st.Skipped++ })
+			return
+		}
+		defer unlock()
+	}
+
+	start := time.Now()
+	s.record(e, func(st *Stats) {
+		st.Running = true
+		st.LastRun = start
+	})
+
+	runCtx, cancel := context.WithTimeout(ctx, e.job.Timeout)
+	defer cancel()
+	err := safeRun(runCtx, e.job)
+	duration := time.Since(start)
+
+	s.record(e, func(st *Stats) {
+		st.Running = false
+		st.Runs++
+		st.LastDuration = duration
+		if err != nil {
+			st.Failures++
+			st.LastError = err.Error()
+		} else {
+			st.LastSuccess = start.Add(duration)
+			st.LastError = ""
+		}
+	})
+
+	if err != nil {
+		log.Error("job failed", "error", err, "duration", duration)
+		return
+	}
+	log.Info("job finished", "duration", duration)
+}
+
+func (s *Scheduler) record(e *entry, update func(*Stats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&e.stats)
+}
+
+// safeRun turns a panicking job into a failed run instead of a dead server
+func safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsAndRecordsStats(t *testing.T) {
+	s := New(&fakeLocker{held: map[string]bool{}})
+
+	var calls atomic.Int64
+	s.Register(Job{
+		Name:     "flaky",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			if calls.Add(1)%2 == 0 {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	})
+	s.Register(Job{
+		Name:     "panics",
+		Interval: 5 * time.Millisecond,
+		Run:      func(ctx context.Context) error { panic("oops") },
+	})
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return calls.Load() >= 4 })
+	s.Stop()
+
+	stats := s.Stats()
+	if stats[0].Runs < 4 || stats[0].Failures < 1 || stats[0].LastSuccess.IsZero() {
+		t.Errorf("flaky stats = %+v", stats[0])
+	}
+	if stats[1].Runs == 0 || stats[1].Failures != stats[1].Runs || stats[1].LastError != "panic: oops" {
+		t.Errorf("panics stats = %+v", stats[1])
+	}
+}
+
+func TestSchedulerSkipsWhenLockedElsewhere(t *testing.T) {
+	locker := &fakeLocker{held: map[string]bool{"busy": true}}
+	s := New(locker)
+
+	var ran atomic.Bool
+	s.Register(Job{
+		Name:     "busy",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			ran.Store(true)
+			return nil
+		},
+	})
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return s.Stats()[0].Skipped >= 2 })
+	s.Stop()
+
+	if ran.Load() {
+		t.Error("job ran while another instance held the lock")
+	}
+}
+
+func TestSchedulerStopCancelsRunningJob(t *testing.T) {
+	s := New(nil)
+
+	started := make(chan struct{})
+	s.Register(Job{
+		Name:     "slow",
+		Interval: time.Millisecond,
+		Timeout:  time.Hour,
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	s.Start(context.Background())
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop didn't return")
+	}
+}
+
+func TestLockKeyIsStable(t *testing.T) {
+	if lockKey("sessions.cleanup") != lockKey("sessions.cleanup") {
+		t.Error("lock key changed between calls")
+	}
+	if lockKey("sessions.cleanup") == lockKey("soft_deleted.purge") {
+		t.Error("different jobs share a lock key")
+	}
+}
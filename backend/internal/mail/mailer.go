@@ -0,0 +1,60 @@
+// Package mail sends transactional email. Handlers depend on the Mailer
+// interface; which implementation backs it is decided by config.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Driver names the Mailer implementation Config picks
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Config says which mailer to build and how. SMTP is only used by the smtp
+// driver and Dir by the file driver.
+type Config struct {
+	Driver string
+	SMTP   SMTPConfig
+	Dir    string
+}
+
+// New builds the mailer cfg describes
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg.SMTP)
+	case DriverFile:
+		return NewFileMailer(cfg.Dir)
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// checkHeaders rejects values that would let a caller smuggle in extra
+// headers
+func checkHeaders(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("mail: header values must not contain newlines")
+	}
+	return nil
+}
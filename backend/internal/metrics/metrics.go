@@ -0,0 +1,331 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// serves them in the Prometheus text exposition format. It covers just what
+// the server needs instead of pulling in the client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets suit request latencies in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample is one labelled value reported by a Func metric
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics in the order they were registered. Registering a
+// name again replaces the earlier metric.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.metrics {
+		if existing.name() == m.name() {
+			r.metrics[i] = m
+			return
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// ServeHTTP writes every metric in the text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	buf.Flush()
+}
+
+// vec holds one value per combination of label values
+type vec[T any] struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*T
+	newT   func() *T
+}
+
+func (v *vec[T]) name() string { return v.metricName }
+
+// with returns the series for values, creating it on first use. The caller
+// must hold v.mu.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.metricName, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = v.newT()
+		v.series[key] = s
+	}
+	return s
+}
+
+// sortedKeys returns series keys in a stable order so scrapes diff cleanly.
+// The caller must hold v.mu.
+func (v *vec[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec[T]) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metricName, escapeHelp(v.help), v.metricName, kind)
+}
+
+// Counter only goes up
+type Counter struct {
+	vec[float64]
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec[float64]{metricName: name, help: help, labels: labels, series: map[string]*float64{}, newT: func() *float64 { return new(float64) }}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series for the label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative, to the series for the label
+// values
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s decreased", c.metricName))
+	}
+	c.mu.Lock()
+	*c.with(values) += delta
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+	if len(c.labels) == 0 && len(c.series) == 0 {
+		// an unlabelled counter reads 0 before its first Inc
+		writeSample(w, c.metricName, nil, nil, 0)
+	}
+	for _, key := range c.sortedKeys() {
+		writeSample(w, c.metricName, c.labels, splitKey(key), *c.series[key])
+	}
+}
+
+// Gauge goes up and down
+type Gauge struct {
+	vec[float64]
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec[float64]{metricName: name, help: help, labels: labels, series: map[string]*float64{}, newT: func() *float64 { return new(float64) }}}
+	r.register(g)
+	return g
+}
+
+// Add adds delta to the series for the label values
+func (g *Gauge) Add(delta float64, values ...string) {
+	g.mu.Lock()
+	*g.with(values) += delta
+	g.mu.Unlock()
+}
+
+func (g *Gauge) Inc(values ...string) { g.Add(1, values...) }
+func (g *Gauge) Dec(values ...string) { g.Add(-1, values...) }
+
+// Set replaces the series for the label values
+func (g *Gauge) Set(value float64, values ...string) {
+	g.mu.Lock()
+	*g.with(values) = value
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.header(w, "gauge")
+	if len(g.labels) == 0 && len(g.series) == 0 {
+		writeSample(w, g.metricName, nil, nil, 0)
+	}
+	for _, key := range g.sortedKeys() {
+		writeSample(w, g.metricName, g.labels, splitKey(key), *g.series[key])
+	}
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	vec[histogramSeries]
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, which
+// must be sorted, and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{buckets: buckets}
+	h.vec = vec[histogramSeries]{metricName: name, help: help, labels: labels, series: map[string]*histogramSeries{}, newT: func() *histogramSeries {
+		return &histogramSeries{counts: make([]uint64, len(buckets))}
+	}}
+	r.register(h)
+	return h
+}
+
+// Observe records value in the series for the label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.with(values)
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range h.sortedKeys() {
+		s := h.series[key]
+		values := splitKey(key)
+		if len(h.labels) == 0 {
+			values = nil
+		}
+		for i, bound := range h.buckets {
+			writeSample(w, h.metricName+"_bucket", labels, append(values, formatFloat(bound)), float64(s.counts[i]))
+		}
+		writeSample(w, h.metricName+"_bucket", labels, append(values, "+Inf"), float64(s.count))
+		writeSample(w, h.metricName+"_sum", h.labels, values, s.sum)
+		writeSample(w, h.metricName+"_count", h.labels, values, float64(s.count))
+	}
+}
+
+// funcMetric reads its samples when scraped, for values kept elsewhere
+// like sql.DB.Stats
+type funcMetric struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+	collect    func() []Sample
+}
+
+// NewGaugeFunc registers a gauge whose samples come from collect at scrape
+// time
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&funcMetric{metricName: name, help: help, kind: "gauge", labels: labels, collect: collect})
+}
+
+// NewCounterFunc registers a counter whose samples come from collect at
+// scrape time
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&funcMetric{metricName: name, help: help, kind: "counter", labels: labels, collect: collect})
+}
+
+func (f *funcMetric) name() string { return f.metricName }
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, escapeHelp(f.help), f.metricName, f.kind)
+	for _, s := range f.collect() {
+		writeSample(w, f.metricName, f.labels, s.Labels, s.Value)
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func splitKey(key string) []string {
+	return strings.Split(key, "\xff")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
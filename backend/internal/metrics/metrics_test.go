@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	return rr.Body.String()
+}
+
+func assertLines(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests.", "route", "status")
+	uploads := r.NewCounter("upload_bytes_total", "Bytes.")
+
+	requests.Inc("/api/posts/{id}", "200")
+	requests.Inc("/api/posts/{id}", "200")
+	requests.Add(3, "/api/users", "404")
+
+	assertLines(t, scrape(t, r),
+		"# HELP requests_total Requests.",
+		"# TYPE requests_total counter",
+		`requests_total{route="/api/posts/{id}",status="200"} 2`,
+		`requests_total{route="/api/users",status="404"} 3`,
+		// unlabelled counters show up before they're used
+		"upload_bytes_total 0",
+	)
+
+	uploads.Add(1024)
+	assertLines(t, scrape(t, r), "upload_bytes_total 1024")
+}
+
+func TestGauge(t *testing.T) {
+	r := NewRegistry()
+	inFlight := r.NewGauge("in_flight", "In flight.")
+
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	assertLines(t, scrape(t, r), "# TYPE in_flight gauge", "in_flight 1")
+
+	inFlight.Set(7)
+	assertLines(t, scrape(t, r), "in_flight 7")
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(2, "/a")
+
+	assertLines(t, scrape(t, r),
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{route="/a",le="0.1"} 1`,
+		`latency_seconds_bucket{route="/a",le="1"} 2`,
+		`latency_seconds_bucket{route="/a",le="+Inf"} 3`,
+		`latency_seconds_sum{route="/a"} 2.55`,
+		`latency_seconds_count{route="/a"} 3`,
+	)
+}
+
+func TestFuncMetrics(t *testing.T) {
+	r := NewRegistry()
+	r.NewGaugeFunc("job_running", "Running.", []string{"job"}, func() []Sample {
+		return []Sample{{Labels: []string{"cleanup"}, Value: 1}}
+	})
+	// registering a name again replaces it
+	r.NewCounterFunc("job_runs_total", "Runs.", []string{"job"}, func() []Sample {
+		return []Sample{{Labels: []string{"cleanup"}, Value: 1}}
+	})
+	r.NewCounterFunc("job_runs_total", "Runs.", []string{"job"}, func() []Sample {
+		return []Sample{{Labels: []string{"cleanup"}, Value: 5}}
+	})
+
+	body := scrape(t, r)
+	assertLines(t, body,
+		`job_running{job="cleanup"} 1`,
+		`job_runs_total{job="cleanup"} 5`,
+	)
+	if strings.Count(body, "# TYPE job_runs_total") != 1 {
+		t.Errorf("job_runs_total written more than once:\n%s", body)
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("escaped_total", "Escaped.", "value")
+	c.Inc("a \"quoted\"\\path\n")
+
+	assertLines(t, scrape(t, r), `escaped_total{value="a \"quoted\"\\path\n"} 1`)
+}
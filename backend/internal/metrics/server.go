@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// HTTP metrics, labelled by route pattern rather than raw path so IDs don't
+// each get their own series
+var (
+	HTTPRequests = Default.NewCounter("pin_http_requests_total",
+		"HTTP requests handled, by route pattern, method and status.", "route", "method", "status")
+	HTTPDuration = Default.NewHistogram("pin_http_request_duration_seconds",
+		"HTTP request latency, by route pattern, method and status.", DefaultBuckets, "route", "method", "status")
+	HTTPInFlight = Default.NewGauge("pin_http_requests_in_flight",
+		"HTTP requests being served right now.")
+)
+
+// Domain metrics
+var (
+	UploadBytes = Default.NewCounter("pin_upload_bytes_total",
+		"Bytes of images stored through the upload endpoint.")
+	PostsCreated = Default.NewCounter("pin_posts_created_total",
+		"Posts created.")
+	Likes = Default.NewCounter("pin_likes_total",
+		"Posts liked.")
+	Comparisons = Default.NewCounter("pin_comparisons_total",
+		"Place comparisons made.")
+)
+
+// RegisterDBStats reports db's connection pool from sql.DB.Stats on every
+// scrape
+func RegisterDBStats(db *sql.DB) {
+	stat := func(read func(sql.DBStats) float64) func() []Sample {
+		return func() []Sample {
+			return []Sample{{Value: read(db.Stats())}}
+		}
+	}
+
+	Default.NewGaugeFunc("pin_db_max_open_connections", "Maximum number of open connections to the database.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	Default.NewGaugeFunc("pin_db_open_connections", "Established connections, in use or idle.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	Default.NewGaugeFunc("pin_db_in_use_connections", "Connections in use.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	Default.NewGaugeFunc("pin_db_idle_connections", "Idle connections.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	Default.NewCounterFunc("pin_db_wait_count_total", "Connections waited for.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	Default.NewCounterFunc("pin_db_wait_duration_seconds_total", "Time spent waiting for a connection.", nil,
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	Default.NewCounterFunc("pin_db_max_idle_closed_total", "Connections closed because of SetMaxIdleConns.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	Default.NewCounterFunc("pin_db_max_lifetime_closed_total", "Connections closed because of SetConnMaxLifetime.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
@@ -5,9 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,44 +18,81 @@ import (
 type AuthMiddleware struct {
 	sessionRepo repository.SessionRepository
 	userRepo    repository.UserRepository
+	tokenRepo   repository.AccessTokenRepository
 	devMode     bool
+	devNetworks []*net.IPNet
 }
 
 type AuthContextKey string
 
 const (
-	UserIDKey    AuthContextKey = "user_id"
-	SessionKey   AuthContextKey = "session"
-	IsDevModeKey AuthContextKey = "is_dev_mode"
+	// access tokens are short lived so a leaked one isn't worth much; the
+	// refresh token keeps the session alive and slides its expiry forward
+	accessTokenTTL = 15 * time.Minute
+	sessionTTL     = 30 * 24 * time.Hour
 )
 
-func NewAuthMiddleware(sessionRepo repository.SessionRepository, userRepo repository.UserRepository) *AuthMiddleware {
-	devMode := false
-	if devModeStr := os.Getenv("DEV_MODE"); devModeStr != "" {
-		if parsed, err := strconv.ParseBool(devModeStr); err == nil {
-			devMode = parsed
-		}
-	}
+// last_seen_at and last_used_at only need to be roughly right, so we write
+// them at most this often per session or token instead of on every request
+const lastSeenInterval = 5 * time.Minute
+
+const (
+	UserIDKey      AuthContextKey = "user_id"
+	RoleKey        AuthContextKey = "role"
+	SessionKey     AuthContextKey = "session"
+	AccessTokenKey AuthContextKey = "access_token"
+	IsDevModeKey   AuthContextKey = "is_dev_mode"
+)
 
-	return &AuthMiddleware{
+// NewAuthMiddleware accepts bearer session tokens, and personal access tokens
+// when tokenRepo is set. With devMode enabled, requests from its allowed
+// networks may skip authentication.
+func NewAuthMiddleware(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, tokenRepo repository.AccessTokenRepository, devMode DevMode) *AuthMiddleware {
+	a := &AuthMiddleware{
 		sessionRepo: sessionRepo,
 		userRepo:    userRepo,
-		devMode:     devMode,
+		tokenRepo:   tokenRepo,
+		devMode:     devMode.Enabled,
+		devNetworks: devMode.AllowedNetworks,
+	}
+
+	// loopback is always allowed, even when no networks were passed
+	if a.devMode && len(a.devNetworks) == 0 {
+		a.devNetworks, _ = ParseDevNetworks("")
 	}
+
+	return a
 }
 
+// RequireAuth only lets signed in sessions through. Personal access tokens
+// are refused, since they should only reach routes that ask for a scope.
 func (a *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return a.authenticate("", next)
+}
+
+// RequireScope is RequireAuth that also accepts personal access tokens
+// granted scope. Sessions hold every scope.
+func (a *AuthMiddleware) RequireScope(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return a.authenticate(scope, next)
+}
+
+func (a *AuthMiddleware) authenticate(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), IsDevModeKey, a.devMode)
+		devBypass := a.devBypass(r)
+		ctx := context.WithValue(r.Context(), IsDevModeKey, devBypass)
 		r = r.WithContext(ctx)
 
 		// In dev mode, create a mock user if no auth is provided
-		if a.devMode {
+		if devBypass {
 			userID := a.getDevUserID(r)
 			if userID != uuid.Nil {
-				ctx = context.WithValue(ctx, UserIDKey, userID)
-				r = r.WithContext(ctx)
-				next.ServeHTTP(w, r)
+				userCtx, err := a.withUser(ctx, userID)
+				if err != nil {
+					http.Error(w, `{"error": "Dev user not found"}`, http.StatusUnauthorized)
+					return
+				}
+				auditDevRequest(r, userID)
+				next.ServeHTTP(w, r.WithContext(userCtx))
 				return
 			}
 		}
@@ -81,6 +117,34 @@ func (a *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(sessionToken, models.AccessTokenPrefix) {
+			token, err := a.getAccessToken(r.Context(), sessionToken)
+			if err != nil {
+				http.Error(w, `{"error": "Invalid or expired access token"}`, http.StatusUnauthorized)
+				return
+			}
+			if scope == "" {
+				http.Error(w, `{"error": "Personal access tokens can't be used here"}`, http.StatusForbidden)
+				return
+			}
+			if !token.HasScope(scope) {
+				http.Error(w, fmt.Sprintf(`{"error": "Access token is missing the %s scope"}`, scope), http.StatusForbidden)
+				return
+			}
+
+			ctx, err = a.withUser(ctx, token.UserID)
+			if err != nil {
+				http.Error(w, `{"error": "Invalid or expired access token"}`, http.StatusUnauthorized)
+				return
+			}
+			ctx = context.WithValue(ctx, AccessTokenKey, token)
+
+			a.touchAccessToken(ctx, token)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Get session from database
 		session, err := a.sessionRepo.GetByToken(r.Context(), sessionToken)
 		if err != nil {
@@ -88,37 +152,78 @@ func (a *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Add user ID and session to context
-		ctx = context.WithValue(ctx, UserIDKey, session.UserID)
+		// Add user ID, role and session to context
+		ctx, err = a.withUser(ctx, session.UserID)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid or expired session"}`, http.StatusUnauthorized)
+			return
+		}
 		ctx = context.WithValue(ctx, SessionKey, session)
 		r = r.WithContext(ctx)
 
+		a.touchSession(ctx, session)
+
 		next.ServeHTTP(w, r)
 	}
 }
 
+// RequireRole is RequireAuth plus a minimum role check. Callers below the
+// required role get a 403.
+func (a *AuthMiddleware) RequireRole(role models.UserRole, next http.HandlerFunc) http.HandlerFunc {
+	return a.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if !GetRoleFromContext(r.Context()).AtLeast(role) {
+			http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// OptionalAuth lets everyone through, attaching the user when the request
+// carries a valid session. Personal access tokens are treated as anonymous;
+// routes that should see them use OptionalScope.
 func (a *AuthMiddleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return a.optional("", next)
+}
+
+// OptionalScope is OptionalAuth that also attaches the user of a personal
+// access token granted scope. Tokens without it read as anonymous.
+func (a *AuthMiddleware) OptionalScope(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return a.optional(scope, next)
+}
+
+func (a *AuthMiddleware) optional(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), IsDevModeKey, a.devMode)
+		devBypass := a.devBypass(r)
+		ctx := context.WithValue(r.Context(), IsDevModeKey, devBypass)
 		r = r.WithContext(ctx)
 
 		// In dev mode, try to get dev user ID
-		if a.devMode {
+		if devBypass {
 			userID := a.getDevUserID(r)
 			if userID != uuid.Nil {
-				ctx = context.WithValue(ctx, UserIDKey, userID)
-				r = r.WithContext(ctx)
+				if userCtx, err := a.withUser(ctx, userID); err == nil {
+					auditDevRequest(r, userID)
+					ctx = userCtx
+				}
 			}
 		} else {
 			// Try to extract session token
 			authHeader := r.Header.Get("Authorization")
 			if authHeader != "" {
 				parts := strings.SplitN(authHeader, " ", 2)
-				if len(parts) == 2 && parts[0] == "Bearer" && parts[1] != "" {
+				if len(parts) == 2 && parts[0] == "Bearer" && strings.HasPrefix(parts[1], models.AccessTokenPrefix) {
+					if token, err := a.getAccessToken(r.Context(), parts[1]); err == nil && scope != "" && token.HasScope(scope) {
+						if userCtx, err := a.withUser(ctx, token.UserID); err == nil {
+							ctx = context.WithValue(userCtx, AccessTokenKey, token)
+						}
+					}
+				} else if len(parts) == 2 && parts[0] == "Bearer" && parts[1] != "" {
 					session, err := a.sessionRepo.GetByToken(r.Context(), parts[1])
 					if err == nil {
-						ctx = context.WithValue(ctx, UserIDKey, session.UserID)
-						ctx = context.WithValue(ctx, SessionKey, session)
+						if userCtx, err := a.withUser(ctx, session.UserID); err == nil {
+							ctx = context.WithValue(userCtx, SessionKey, session)
+						}
 					}
 				}
 			}
@@ -129,9 +234,27 @@ func (a *AuthMiddleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// withUser loads the user so their role travels with the request. Sessions
+// belonging to deleted users fail here.
+func (a *AuthMiddleware) withUser(ctx context.Context, userID uuid.UUID) (context.Context, error) {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ctx, err
+	}
+
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	ctx = context.WithValue(ctx, UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, RoleKey, role)
+	return ctx, nil
+}
+
 func (a *AuthMiddleware) getDevUserID(r *http.Request) uuid.UUID {
 	// In dev mode, check for a dev-user-id header or query param
-	devUserID := r.Header.Get("X-Dev-User-ID")
+	devUserID := r.Header.Get(DevUserHeader)
 	if devUserID == "" {
 		devUserID = r.URL.Query().Get("dev_user_id")
 	}
@@ -153,12 +276,14 @@ func (a *AuthMiddleware) getOrCreateDevUser(ctx context.Context) uuid.UUID {
 		return devUser.ID
 	}
 
-	// Create a new dev user
+	// Create a new dev user. It's a plain user like any other; promote it
+	// with the bootstrap query in migrations/README.md to test admin routes.
 	devUser = &models.User{
 		ID:          uuid.New(),
 		Email:       "dev@localhost",
 		Username:    stringPtr("devuser"),
 		DisplayName: stringPtr("Dev User"),
+		Role:        models.RoleUser,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -171,19 +296,30 @@ func (a *AuthMiddleware) getOrCreateDevUser(ctx context.Context) uuid.UUID {
 	return devUser.ID
 }
 
-func (a *AuthMiddleware) CreateSession(ctx context.Context, userID uuid.UUID) (*models.Session, error) {
+func (a *AuthMiddleware) CreateSession(ctx context.Context, userID uuid.UUID, client ClientInfo) (*models.Session, error) {
 	sessionToken, err := generateSessionToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session token: %w", err)
 	}
+	refreshToken, err := generateSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
 
+	now := time.Now()
 	session := &models.Session{
-		ID:           uuid.New(),
-		UserID:       userID,
-		SessionToken: sessionToken,
-		ExpiresAt:    time.Now().Add(30 * 24 * time.Hour), // 30 days
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:              uuid.New(),
+		UserID:          userID,
+		SessionToken:    sessionToken,
+		RefreshToken:    refreshToken,
+		UserAgent:       optionalString(client.UserAgent),
+		IPAddress:       optionalString(client.IPAddress),
+		AppVersion:      optionalString(client.AppVersion),
+		LastSeenAt:      &now,
+		AccessExpiresAt: now.Add(accessTokenTTL),
+		ExpiresAt:       now.Add(sessionTTL),
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
 	if err := a.sessionRepo.Create(ctx, session); err != nil {
@@ -193,10 +329,66 @@ func (a *AuthMiddleware) CreateSession(ctx context.Context, userID uuid.UUID) (*
 	return session, nil
 }
 
+// RefreshSession rotates a refresh token into a new access/refresh pair and
+// slides the session's expiry forward. A refresh token that was already
+// used revokes the session and returns repository.ErrRefreshTokenReused.
+func (a *AuthMiddleware) RefreshSession(ctx context.Context, refreshToken string) (*models.Session, error) {
+	sessionToken, err := generateSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	newRefreshToken, err := generateSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	return a.sessionRepo.Rotate(ctx, refreshToken, sessionToken, newRefreshToken, now.Add(accessTokenTTL), now.Add(sessionTTL))
+}
+
 func (a *AuthMiddleware) DeleteSession(ctx context.Context, sessionToken string) error {
 	return a.sessionRepo.DeleteByToken(ctx, sessionToken)
 }
 
+// touchSession bumps last_seen_at when it's gone stale. A failed write only
+// costs accuracy in the sessions list, so it never fails the request.
+func (a *AuthMiddleware) touchSession(ctx context.Context, session *models.Session) {
+	now := time.Now()
+	if session.LastSeenAt != nil && now.Sub(*session.LastSeenAt) < lastSeenInterval {
+		return
+	}
+	if err := a.sessionRepo.Touch(ctx, session.ID, now); err == nil {
+		session.LastSeenAt = &now
+	}
+}
+
+func (a *AuthMiddleware) getAccessToken(ctx context.Context, token string) (*models.AccessToken, error) {
+	if a.tokenRepo == nil {
+		return nil, repository.ErrAccessTokenNotFound
+	}
+	return a.tokenRepo.GetByToken(ctx, token)
+}
+
+// touchAccessToken is touchSession for personal access tokens
+func (a *AuthMiddleware) touchAccessToken(ctx context.Context, token *models.AccessToken) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < lastSeenInterval {
+		return
+	}
+	if err := a.tokenRepo.Touch(ctx, token.ID, now); err == nil {
+		token.LastUsedAt = &now
+	}
+}
+
+// GenerateAccessToken returns a new personal access token
+func GenerateAccessToken() (string, error) {
+	token, err := generateSessionToken()
+	if err != nil {
+		return "", err
+	}
+	return models.AccessTokenPrefix + token, nil
+}
+
 func generateSessionToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -209,17 +401,49 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // Helper functions to extract data from context
 func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
 	return userID, ok
 }
 
+// GetRoleFromContext returns the caller's role, defaulting to RoleUser
+func GetRoleFromContext(ctx context.Context) models.UserRole {
+	role, ok := ctx.Value(RoleKey).(models.UserRole)
+	if !ok || role == "" {
+		return models.RoleUser
+	}
+	return role
+}
+
 func GetSessionFromContext(ctx context.Context) (*models.Session, bool) {
 	session, ok := ctx.Value(SessionKey).(*models.Session)
 	return session, ok
 }
 
+// GetAccessTokenFromContext returns the personal access token the request
+// was made with, if it wasn't made with a session
+func GetAccessTokenFromContext(ctx context.Context) (*models.AccessToken, bool) {
+	token, ok := ctx.Value(AccessTokenKey).(*models.AccessToken)
+	return token, ok
+}
+
+// HasScope reports whether the caller may act with scope. Everything but a
+// personal access token carries every scope.
+func HasScope(ctx context.Context, scope models.Scope) bool {
+	if token, ok := GetAccessTokenFromContext(ctx); ok {
+		return token.HasScope(scope)
+	}
+	return true
+}
+
 func IsDevModeFromContext(ctx context.Context) bool {
 	isDevMode, ok := ctx.Value(IsDevModeKey).(bool)
 	return ok && isDevMode
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/repository"
+)
+
+// hashedSessions keeps sessions under the SHA-256 of their token like the
+// sessions table does, so only the raw token finds one again
+type hashedSessions struct {
+	repository.SessionRepository
+	byHash map[string]*models.Session
+}
+
+func digest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *hashedSessions) Create(ctx context.Context, session *models.Session) error {
+	stored := *session
+	stored.SessionToken = ""
+	stored.RefreshToken = ""
+	s.byHash[digest(session.SessionToken)] = &stored
+	return nil
+}
+
+func (s *hashedSessions) GetByToken(ctx context.Context, token string) (*models.Session, error) {
+	session, ok := s.byHash[digest(token)]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+type stubUsers struct {
+	repository.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (s *stubUsers) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func TestAuthMiddleware_RawTokenAuthenticates(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Role: models.RoleUser}
+	sessions := &hashedSessions{byHash: make(map[string]*models.Session)}
+	a := NewAuthMiddleware(sessions, &stubUsers{users: map[uuid.UUID]*models.User{user.ID: user}}, nil, DevMode{})
+
+	session, err := a.CreateSession(context.Background(), user.ID, ClientInfo{})
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	handler := a.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if id, _ := GetUserIDFromContext(r.Context()); id != user.ID {
+			t.Errorf("user in context = %s, want %s", id, user.ID)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"raw token", session.SessionToken, http.StatusNoContent},
+		{"stored digest", digest(session.SessionToken), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/users/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+type stubAccessTokens struct {
+	repository.AccessTokenRepository
+	tokens map[string]*models.AccessToken
+}
+
+func (s *stubAccessTokens) GetByToken(ctx context.Context, token string) (*models.AccessToken, error) {
+	accessToken, ok := s.tokens[token]
+	if !ok {
+		return nil, repository.ErrAccessTokenNotFound
+	}
+	return accessToken, nil
+}
+
+func (s *stubAccessTokens) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return nil
+}
+
+func TestAuthMiddleware_OptionalScope(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Role: models.RoleUser}
+	tokens := &stubAccessTokens{tokens: map[string]*models.AccessToken{
+		models.AccessTokenPrefix + "reader": {ID: uuid.New(), UserID: user.ID, Scopes: []models.Scope{models.ScopePostsRead}, ExpiresAt: time.Now().Add(time.Hour)},
+		models.AccessTokenPrefix + "rater":  {ID: uuid.New(), UserID: user.ID, Scopes: []models.Scope{models.ScopeRatingsWrite}, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	a := NewAuthMiddleware(&hashedSessions{byHash: make(map[string]*models.Session)}, &stubUsers{users: map[uuid.UUID]*models.User{user.ID: user}}, tokens, DevMode{})
+
+	viewer := func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := GetUserIDFromContext(r.Context()); ok {
+			w.Write([]byte(id.String()))
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		token   string
+		want    string
+	}{
+		{"token with the read scope", a.OptionalScope(models.ScopePostsRead, viewer), models.AccessTokenPrefix + "reader", user.ID.String()},
+		{"token without the read scope", a.OptionalScope(models.ScopePostsRead, viewer), models.AccessTokenPrefix + "rater", ""},
+		{"token on a route without a scope", a.OptionalAuth(viewer), models.AccessTokenPrefix + "reader", ""},
+		{"unknown token", a.OptionalScope(models.ScopePostsRead, viewer), models.AccessTokenPrefix + "unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/posts", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("status = %v, want %v", rr.Code, http.StatusOK)
+			}
+			if got := rr.Body.String(); got != tt.want {
+				t.Errorf("viewer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
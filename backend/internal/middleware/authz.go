@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/models"
+)
+
+var (
+	ErrNotAuthenticated = errors.New("user not authenticated")
+	ErrForbidden        = errors.New("forbidden")
+)
+
+// Authorize checks the authenticated caller against the owner of a resource.
+// Callers holding at least the override role may act on resources they
+// don't own. It returns the caller's user ID when they are allowed through.
+func Authorize(ctx context.Context, ownerID uuid.UUID, override models.UserRole) (uuid.UUID, error) {
+	userID, ok := GetUserIDFromContext(ctx)
+	if !ok || userID == uuid.Nil {
+		return uuid.Nil, ErrNotAuthenticated
+	}
+
+	if ownerID != uuid.Nil && userID == ownerID {
+		return userID, nil
+	}
+
+	if GetRoleFromContext(ctx).AtLeast(override) {
+		return userID, nil
+	}
+
+	return userID, ErrForbidden
+}
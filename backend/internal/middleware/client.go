@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+)
+
+// the mobile app sends its version with every request
+const AppVersionHeader = "X-App-Version"
+
+// ClientInfo describes the device a session was created from
+type ClientInfo struct {
+	UserAgent  string
+	IPAddress  string
+	AppVersion string
+}
+
+func ClientInfoFromRequest(r *http.Request) ClientInfo {
+	return ClientInfo{
+		UserAgent:  r.UserAgent(),
+		IPAddress:  ClientIP(r),
+		AppVersion: r.Header.Get(AppVersionHeader),
+	}
+}
+
+// ClientIP returns the address the request came from
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseDevNetworks(t *testing.T) {
+	networks, err := ParseDevNetworks("")
+	if err != nil || len(networks) != len(loopback) {
+		t.Errorf("ParseDevNetworks(\"\") = %v, %v, want just loopback", networks, err)
+	}
+
+	for _, raw := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1,"} {
+		if _, err := ParseDevNetworks(raw); err == nil {
+			t.Errorf("ParseDevNetworks(%q) accepted a bad entry", raw)
+		}
+	}
+}
+
+func TestAuthMiddleware_DevBypass(t *testing.T) {
+	networks, err := ParseDevNetworks("10.1.0.0/16, 192.0.2.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthMiddleware(nil, nil, nil, DevMode{Enabled: true, AllowedNetworks: networks})
+
+	tests := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{"127.0.0.1:50000", true},
+		{"[::1]:50000", true},
+		{"10.1.2.3:50000", true},
+		{"192.0.2.7:50000", true},
+		{"192.0.2.8:50000", false},
+		{"203.0.113.9:50000", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/posts", nil)
+		req.RemoteAddr = tt.remoteAddr
+		// forwarding headers must not count
+		req.Header.Set("X-Forwarded-For", "127.0.0.1")
+
+		if got := a.devBypass(req); got != tt.want {
+			t.Errorf("devBypass(%s) = %v, want %v", tt.remoteAddr, got, tt.want)
+		}
+	}
+
+	off := NewAuthMiddleware(nil, nil, nil, DevMode{AllowedNetworks: networks})
+	req := httptest.NewRequest("GET", "/api/posts", nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	if off.devBypass(req) {
+		t.Error("dev mode bypass used while dev mode is off")
+	}
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessTokenPrefix starts every personal access token, so they can be told
+// apart from session tokens without a database lookup and are easy to spot
+// if they leak into logs or commits
+const AccessTokenPrefix = "pin_pat_"
+
+// Scope limits what a personal access token can do. Sessions carry every
+// scope.
+type Scope string
+
+const (
+	ScopePostsRead          Scope = "posts:read"
+	ScopePostsWrite         Scope = "posts:write"
+	ScopeCommentsRead       Scope = "comments:read"
+	ScopeCommentsWrite      Scope = "comments:write"
+	ScopePlacesWrite        Scope = "places:write"
+	ScopeRatingsRead        Scope = "ratings:read"
+	ScopeRatingsWrite       Scope = "ratings:write"
+	ScopeFollowsRead        Scope = "follows:read"
+	ScopeFollowsWrite       Scope = "follows:write"
+	ScopeNotificationsRead  Scope = "notifications:read"
+	ScopeNotificationsWrite Scope = "notifications:write"
+	ScopeProfileRead        Scope = "profile:read"
+	ScopeProfileWrite       Scope = "profile:write"
+)
+
+// Scopes lists every scope a token can be granted
+var Scopes = []Scope{
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeCommentsRead,
+	ScopeCommentsWrite,
+	ScopePlacesWrite,
+	ScopeRatingsRead,
+	ScopeRatingsWrite,
+	ScopeFollowsRead,
+	ScopeFollowsWrite,
+	ScopeNotificationsRead,
+	ScopeNotificationsWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+// IsValid checks if the scope is one we know about
+func (s Scope) IsValid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessToken is a personal access token a user created for scripts and
+// integrations. Only a digest of the token is stored; Token is set when it
+// is created and never again.
+type AccessToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Scopes     []Scope    `json:"scopes" db:"scopes"`
+	Token      string     `json:"-" db:"-"`
+	TokenHint  string     `json:"token_hint" db:"token_hint"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *AccessToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessTokenCreateRequest represents the data needed to create a personal
+// access token. ExpiresInDays defaults to 90.
+type AccessTokenCreateRequest struct {
+	Name          string  `json:"name" validate:"required,max=100"`
+	Scopes        []Scope `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays *int    `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=365"`
+}
+
+// AccessTokenResponse is a token as shown in the tokens list. Token is only
+// filled in the response to creating it.
+type AccessTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []Scope    `json:"scopes"`
+	Token      string     `json:"token,omitempty"`
+	TokenHint  string     `json:"token_hint"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *AccessToken) ToResponse() AccessTokenResponse {
+	return AccessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		Token:      t.Token,
+		TokenHint:  t.TokenHint,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
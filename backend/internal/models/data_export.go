@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending DataExportStatus = "pending"
+	DataExportRunning DataExportStatus = "running"
+	DataExportReady   DataExportStatus = "ready"
+	DataExportFailed  DataExportStatus = "failed"
+	// DataExportExpired exports had their archive removed
+	DataExportExpired DataExportStatus = "expired"
+)
+
+// DataExport is a user's request for a copy of their data. The archive is
+// built in the background and kept until ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	UserID      uuid.UUID        `json:"user_id" db:"user_id"`
+	Status      DataExportStatus `json:"status" db:"status"`
+	SizeBytes   *int64           `json:"size_bytes,omitempty" db:"size_bytes"`
+	Error       *string          `json:"-" db:"error"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty" db:"expires_at"`
+	StartedAt   *time.Time       `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+}
+
+// FileName is the archive's name in the export directory
+func (e *DataExport) FileName() string {
+	return e.ID.String() + ".zip"
+}
+
+type DataExportResponse struct {
+	ID          uuid.UUID        `json:"id"`
+	Status      DataExportStatus `json:"status"`
+	SizeBytes   *int64           `json:"size_bytes,omitempty"`
+	DownloadURL *string          `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+func (e *DataExport) ToResponse() DataExportResponse {
+	resp := DataExportResponse{
+		ID:          e.ID,
+		Status:      e.Status,
+		SizeBytes:   e.SizeBytes,
+		ExpiresAt:   e.ExpiresAt,
+		CompletedAt: e.CompletedAt,
+		CreatedAt:   e.CreatedAt,
+	}
+	if e.Status == DataExportReady {
+		url := "/api/exports/" + e.ID.String() + "/download"
+		resp.DownloadURL = &url
+	}
+	return resp
+}
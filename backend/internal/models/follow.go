@@ -6,13 +6,23 @@ import (
 	"github.com/google/uuid"
 )
 
+// FollowStatus is whether a follow has been approved. Follows of public
+// accounts are accepted straight away; private accounts approve requests.
+type FollowStatus string
+
+const (
+	FollowPending  FollowStatus = "pending"
+	FollowAccepted FollowStatus = "accepted"
+)
+
 // Follow represents a follow relationship between users
 type Follow struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	FollowerID  uuid.UUID `json:"follower_id" db:"follower_id"`   // User who is following
-	FollowingID uuid.UUID `json:"following_id" db:"following_id"` // User being followed
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID    `json:"id" db:"id"`
+	FollowerID  uuid.UUID    `json:"follower_id" db:"follower_id"`   // User who is following
+	FollowingID uuid.UUID    `json:"following_id" db:"following_id"` // User being followed
+	Status      FollowStatus `json:"status" db:"status"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
 }
 
 // FollowRequest represents the data needed to create a follow relationship
@@ -22,10 +32,11 @@ type FollowRequest struct {
 
 // FollowResponse represents a follow relationship in API responses
 type FollowResponse struct {
-	ID          uuid.UUID `json:"id"`
-	FollowerID  uuid.UUID `json:"follower_id"`
-	FollowingID uuid.UUID `json:"following_id"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          uuid.UUID    `json:"id"`
+	FollowerID  uuid.UUID    `json:"follower_id"`
+	FollowingID uuid.UUID    `json:"following_id"`
+	Status      FollowStatus `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
 // UserStats represents user statistics
@@ -42,6 +53,7 @@ func (f *Follow) ToResponse() FollowResponse {
 		ID:          f.ID,
 		FollowerID:  f.FollowerID,
 		FollowingID: f.FollowingID,
+		Status:      f.Status,
 		CreatedAt:   f.CreatedAt,
 	}
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLink is a one-time sign in token mailed to an address. Only a digest
+// of the token is stored; Token is set when it is created.
+type MagicLink struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Email     string     `json:"email" db:"email"`
+	Token     string     `json:"-" db:"-"`
+	IPAddress *string    `json:"ip_address,omitempty" db:"ip_address"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MagicLinkRequest asks for a sign in link to be mailed to Email
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// MagicLinkRedeemRequest trades the token from a sign in link for a session
+type MagicLinkRedeemRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -12,6 +12,9 @@ const (
 	NotificationTypeLikePost     NotificationType = "like_post"
 	NotificationTypeCommentPost  NotificationType = "comment_post"
 	NotificationTypeCommentReply NotificationType = "comment_reply"
+	// NotificationTypeDataExport tells a user their data export can be
+	// downloaded; they are their own actor
+	NotificationTypeDataExport NotificationType = "data_export_ready"
 )
 
 type Notification struct {
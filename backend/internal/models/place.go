@@ -19,6 +19,7 @@ type Place struct {
 	Name       string         `json:"name" db:"name"`
 	Geometry   *string        `json:"geometry,omitempty" db:"geometry"` // PostGIS geometry as WKT
 	Properties map[string]any `json:"properties,omitempty" db:"properties"`
+	CreatedBy  *uuid.UUID     `json:"created_by,omitempty" db:"created_by"`
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
 	DeletedAt  *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -74,6 +75,7 @@ type PlaceResponse struct {
 	Name       string         `json:"name"`
 	Geometry   *string        `json:"geometry,omitempty"`
 	Properties map[string]any `json:"properties,omitempty"`
+	CreatedBy  *uuid.UUID     `json:"created_by,omitempty"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 }
@@ -85,6 +87,7 @@ func (p *Place) ToResponse() PlaceResponse {
 		Name:       p.Name,
 		Geometry:   p.Geometry,
 		Properties: p.Properties,
+		CreatedBy:  p.CreatedBy,
 		CreatedAt:  p.CreatedAt,
 		UpdatedAt:  p.UpdatedAt,
 	}
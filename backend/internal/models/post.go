@@ -6,14 +6,25 @@ import (
 	"github.com/google/uuid"
 )
 
+// PostVisibility is who can see a post, on top of the author's account
+// being private or blocking the viewer
+type PostVisibility string
+
+const (
+	PostVisibilityPublic    PostVisibility = "public"
+	PostVisibilityFollowers PostVisibility = "followers"
+	PostVisibilityOnlyMe    PostVisibility = "only_me"
+)
+
 type Post struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
-	PlaceID     uuid.UUID  `json:"place_id" db:"place_id"`
-	Description *string    `json:"description,omitempty" db:"description"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID          uuid.UUID      `json:"id" db:"id"`
+	UserID      uuid.UUID      `json:"user_id" db:"user_id"`
+	PlaceID     uuid.UUID      `json:"place_id" db:"place_id"`
+	Description *string        `json:"description,omitempty" db:"description"`
+	Visibility  PostVisibility `json:"visibility" db:"visibility"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type PostImage struct {
@@ -49,14 +60,16 @@ type Comment struct {
 
 // PostCreateRequest represents the data needed to create a new post
 type PostCreateRequest struct {
-	PlaceID     uuid.UUID `json:"place_id" validate:"required"`
-	Description *string   `json:"description,omitempty" validate:"omitempty,max=2000"`
-	Images      []string  `json:"images,omitempty" validate:"omitempty,dive,required"`
+	PlaceID     uuid.UUID       `json:"place_id" validate:"required"`
+	Description *string         `json:"description,omitempty" validate:"omitempty,max=2000"`
+	Visibility  *PostVisibility `json:"visibility,omitempty" validate:"omitempty,oneof=public followers only_me"`
+	Images      []string        `json:"images,omitempty" validate:"omitempty,dive,required"`
 }
 
 // PostUpdateRequest represents the data that can be updated for a post
 type PostUpdateRequest struct {
-	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"`
+	Description *string         `json:"description,omitempty" validate:"omitempty,max=2000"`
+	Visibility  *PostVisibility `json:"visibility,omitempty" validate:"omitempty,oneof=public followers only_me"`
 }
 
 // PostResponse represents the post data returned in API responses
@@ -65,6 +78,7 @@ type PostResponse struct {
 	UserID        uuid.UUID      `json:"user_id"`
 	PlaceID       uuid.UUID      `json:"place_id"`
 	Description   *string        `json:"description,omitempty"`
+	Visibility    PostVisibility `json:"visibility"`
 	Images        []PostImage    `json:"images,omitempty"`
 	Place         *PlaceResponse `json:"place,omitempty"`
 	User          *UserResponse  `json:"user,omitempty"`
@@ -82,6 +96,7 @@ func (p *Post) ToResponse() PostResponse {
 		UserID:      p.UserID,
 		PlaceID:     p.PlaceID,
 		Description: p.Description,
+		Visibility:  p.Visibility,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 	}
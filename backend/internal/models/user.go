@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
+// OAuthProvider is the name of a configured sign in provider. Google and
+// Apple are built in, anything else comes from config.
 type OAuthProvider string
 
 const (
@@ -13,17 +15,58 @@ const (
 	OAuthProviderApple  OAuthProvider = "apple"
 )
 
+type UserRole string
+
+const (
+	RoleUser      UserRole = "user"
+	RoleModerator UserRole = "moderator"
+	RoleAdmin     UserRole = "admin"
+)
+
+var roleRank = map[UserRole]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// Valid reports whether r is one of the known roles
+func (r UserRole) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast reports whether r grants every permission that min does.
+// Unknown roles never satisfy a requirement.
+func (r UserRole) AtLeast(min UserRole) bool {
+	have, ok := roleRank[r]
+	if !ok {
+		return false
+	}
+	want, ok := roleRank[min]
+	if !ok {
+		return false
+	}
+	return have >= want
+}
+
 type User struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	Email       string     `json:"email" db:"email"`
-	Username    *string    `json:"username,omitempty" db:"username"`
-	Bio         *string    `json:"bio,omitempty" db:"bio"`
-	Location    *string    `json:"location,omitempty" db:"location"`
-	DisplayName *string    `json:"display_name,omitempty" db:"display_name"`
-	PfpURL      *string    `json:"pfp_url,omitempty" db:"pfp_url"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID          uuid.UUID `json:"id" db:"id"`
+	Email       string    `json:"email" db:"email"`
+	Username    *string   `json:"username,omitempty" db:"username"`
+	Bio         *string   `json:"bio,omitempty" db:"bio"`
+	Location    *string   `json:"location,omitempty" db:"location"`
+	DisplayName *string   `json:"display_name,omitempty" db:"display_name"`
+	PfpURL      *string   `json:"pfp_url,omitempty" db:"pfp_url"`
+	Role        UserRole  `json:"role" db:"role"`
+	// IsPrivate accounts only show their posts and follow lists to
+	// approved followers
+	IsPrivate bool       `json:"is_private" db:"is_private"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// DeletionScheduledAt is when the account will be erased, if its
+	// owner has asked for that and not cancelled
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
 }
 
 type OAuthAccount struct {
@@ -32,6 +75,7 @@ type OAuthAccount struct {
 	Provider       OAuthProvider `json:"provider" db:"provider"`
 	ProviderID     string        `json:"provider_id" db:"provider_id"`
 	ProviderEmail  *string       `json:"provider_email,omitempty" db:"provider_email"`
+	EmailVerified  bool          `json:"provider_email_verified" db:"provider_email_verified"`
 	ProviderName   *string       `json:"provider_name,omitempty" db:"provider_name"`
 	AccessToken    *string       `json:"access_token,omitempty" db:"access_token"`
 	RefreshToken   *string       `json:"refresh_token,omitempty" db:"refresh_token"`
@@ -41,26 +85,101 @@ type OAuthAccount struct {
 	DeletedAt      *time.Time    `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// OAuthAccountResponse is a linked identity as shown to its owner, without
+// the provider tokens
+type OAuthAccountResponse struct {
+	ID            uuid.UUID     `json:"id"`
+	Provider      OAuthProvider `json:"provider"`
+	ProviderEmail *string       `json:"provider_email,omitempty"`
+	ProviderName  *string       `json:"provider_name,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func (a *OAuthAccount) ToResponse() OAuthAccountResponse {
+	return OAuthAccountResponse{
+		ID:            a.ID,
+		Provider:      a.Provider,
+		ProviderEmail: a.ProviderEmail,
+		ProviderName:  a.ProviderName,
+		CreatedAt:     a.CreatedAt,
+	}
+}
+
 type Session struct {
 	ID           uuid.UUID  `json:"id" db:"id"`
 	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
-	SessionToken string     `json:"session_token" db:"session_token"`
-	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt    *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	SessionToken string     `json:"session_token" db:"-"`
+	UserAgent    *string    `json:"user_agent,omitempty" db:"user_agent"`
+	IPAddress    *string    `json:"ip_address,omitempty" db:"ip_address"`
+	AppVersion   *string    `json:"app_version,omitempty" db:"app_version"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`
+	// SessionToken stops working at AccessExpiresAt; the session itself
+	// lives until ExpiresAt, which moves forward on every refresh. Only its
+	// digest is stored, so it is empty on sessions read back from the db
+	AccessExpiresAt time.Time  `json:"access_expires_at" db:"access_expires_at"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// RefreshToken is only set when the session is created or refreshed;
+	// its digest lives in session_refresh_tokens, not on the session row
+	RefreshToken string `json:"-" db:"-"`
+}
+
+// SessionResponse is a session as shown in the devices list, without its
+// token. Current marks the session making the request.
+type SessionResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	IPAddress  *string    `json:"ip_address,omitempty"`
+	AppVersion *string    `json:"app_version,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Current    bool       `json:"current"`
+}
+
+func (s *Session) ToResponse() SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		AppVersion: s.AppVersion,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		CreatedAt:  s.CreatedAt,
+	}
 }
 
 type OAuthState struct {
 	ID           uuid.UUID     `json:"id" db:"id"`
 	State        string        `json:"state" db:"state"`
 	CodeVerifier *string       `json:"code_verifier,omitempty" db:"code_verifier"`
+	Nonce        *string       `json:"nonce,omitempty" db:"nonce"`
 	Provider     OAuthProvider `json:"provider" db:"provider"`
 	RedirectURL  *string       `json:"redirect_url,omitempty" db:"redirect_url"`
+	UserID       *uuid.UUID    `json:"user_id,omitempty" db:"user_id"`
 	ExpiresAt    time.Time     `json:"expires_at" db:"expires_at"`
 	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
 }
 
+// OAuthExchangeCode is the one-time code the callback hands to the app in
+// place of tokens. Only a digest is stored; Code is set when it is created.
+type OAuthExchangeCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Code      string     `json:"-" db:"-"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OAuthExchangeRequest trades an exchange code for a session
+type OAuthExchangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
 // UserCreateRequest represents the data needed to create a new user
 type UserCreateRequest struct {
 	Email       string  `json:"email" validate:"required,email"`
@@ -78,6 +197,17 @@ type UserUpdateRequest struct {
 	Location    *string `json:"location,omitempty" validate:"omitempty,max=100"`
 	DisplayName *string `json:"display_name,omitempty" validate:"omitempty,max=100"`
 	PfpURL      *string `json:"pfp_url,omitempty" validate:"omitempty,url"`
+	IsPrivate   *bool   `json:"is_private,omitempty"`
+}
+
+// AccountDeletionResponse tells the owner when their account goes away
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// UserRoleUpdateRequest represents an admin changing another user's role
+type UserRoleUpdateRequest struct {
+	Role UserRole `json:"role" validate:"required,oneof=user moderator admin"`
 }
 
 // UserResponse represents the user data returned in API responses
@@ -89,8 +219,12 @@ type UserResponse struct {
 	Location    *string   `json:"location,omitempty"`
 	DisplayName *string   `json:"display_name,omitempty"`
 	PfpURL      *string   `json:"pfp_url,omitempty"`
+	Role        UserRole  `json:"role,omitempty"`
+	IsPrivate   bool      `json:"is_private"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
 }
 
 // ToResponse converts a User to UserResponse
@@ -103,7 +237,11 @@ func (u *User) ToResponse() UserResponse {
 		Location:    u.Location,
 		DisplayName: u.DisplayName,
 		PfpURL:      u.PfpURL,
+		Role:        u.Role,
+		IsPrivate:   u.IsPrivate,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
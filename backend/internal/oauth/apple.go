@@ -0,0 +1,198 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	appleIssuer         = "https://appleid.apple.com"
+	defaultAppleJWKSURL = "https://appleid.apple.com/auth/keys"
+
+	// Apple allows client secrets to live up to six months; we mint short
+	// ones and reuse them until they're close to expiring
+	appleClientSecretTTL = 24 * time.Hour
+)
+
+// AppleProvider is Sign in with Apple. It speaks OIDC but doesn't fit the
+// generic provider: the client secret is a JWT we sign ourselves, the
+// callback is a form post, and the user's name only ever shows up in the
+// callback parameters on their first sign in.
+type AppleProvider struct {
+	name     string
+	oauth    *oauth2.Config
+	client   *http.Client
+	secret   *appleClientSecret
+	verifier *oidc.Verifier
+}
+
+func NewAppleProvider(cfg ProviderConfig, client *http.Client) (*AppleProvider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	p := &AppleProvider{
+		name: cfg.Name,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://appleid.apple.com/auth/authorize",
+				TokenURL: "https://appleid.apple.com/auth/token",
+			},
+		},
+		client: client,
+	}
+	if len(p.oauth.Scopes) == 0 {
+		p.oauth.Scopes = []string{"name", "email"}
+	}
+
+	// without a signing key we fall back to a pre-generated client secret
+	if cfg.PrivateKey != "" {
+		// env files usually carry the PEM on one line with escaped newlines
+		pemData := strings.ReplaceAll(cfg.PrivateKey, `\n`, "\n")
+		key, err := oidc.ParseECPrivateKey([]byte(pemData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Apple private key: %w", err)
+		}
+		p.secret = &appleClientSecret{
+			teamID:   cfg.TeamID,
+			keyID:    cfg.KeyID,
+			clientID: cfg.ClientID,
+			key:      key,
+		}
+	}
+
+	jwksURL := cfg.JWKSURL
+	if jwksURL == "" {
+		jwksURL = defaultAppleJWKSURL
+	}
+	p.verifier = oidc.NewVerifier(oidc.NewKeySet(jwksURL, client), appleIssuer, cfg.ClientID)
+
+	return p, nil
+}
+
+func (p *AppleProvider) Name() string {
+	return p.name
+}
+
+func (p *AppleProvider) AuthCodeURL(ctx context.Context, state *models.OAuthState) (string, error) {
+	// Apple only returns the email scope when the callback is a form post
+	opts := append(authCodeOptions(state), oauth2.SetAuthURLParam("response_mode", "form_post"))
+	return p.oauth.AuthCodeURL(state.State, opts...), nil
+}
+
+func (p *AppleProvider) Exchange(ctx context.Context, code string, state *models.OAuthState) (*oauth2.Token, error) {
+	cfg, err := p.configForExchange()
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	return cfg.Exchange(ctx, code, exchangeOptions(state)...)
+}
+
+// configForExchange returns a copy of the config carrying a freshly signed
+// client secret
+func (p *AppleProvider) configForExchange() (*oauth2.Config, error) {
+	if p.secret == nil {
+		return p.oauth, nil
+	}
+
+	secret, err := p.secret.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := *p.oauth
+	cfg.ClientSecret = secret
+	return &cfg, nil
+}
+
+// UserInfo verifies the id_token returned alongside the access token. Apple
+// has no userinfo endpoint; the name comes from the "user" callback field.
+func (p *AppleProvider) UserInfo(ctx context.Context, token *oauth2.Token, state *models.OAuthState, params url.Values) (*UserInfo, error) {
+	claims, err := verifyIDToken(ctx, p.verifier, token, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserInfo{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          parseAppleUser(params.Get("user")),
+	}, nil
+}
+
+// appleUser is the JSON blob Apple posts alongside the code on a user's
+// first sign in
+type appleUser struct {
+	Name struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	} `json:"name"`
+	Email string `json:"email"`
+}
+
+func parseAppleUser(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	var u appleUser
+	if err := json.Unmarshal([]byte(raw), &u); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(u.Name.FirstName + " " + u.Name.LastName)
+}
+
+// appleClientSecret mints the ES256-signed JWT Apple expects in place of a
+// static client secret
+type appleClientSecret struct {
+	teamID   string
+	keyID    string
+	clientID string
+	key      *ecdsa.PrivateKey
+
+	mu        sync.Mutex
+	cached    string
+	expiresAt time.Time
+}
+
+func (s *appleClientSecret) Get() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.cached != "" && now.Add(time.Minute).Before(s.expiresAt) {
+		return s.cached, nil
+	}
+
+	expiresAt := now.Add(appleClientSecretTTL)
+	secret, err := oidc.Sign(map[string]any{
+		"iss": s.teamID,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+		"aud": appleIssuer,
+		"sub": s.clientID,
+	}, s.keyID, s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Apple client secret: %w", err)
+	}
+
+	s.cached = secret
+	s.expiresAt = expiresAt
+	return secret, nil
+}
@@ -0,0 +1,142 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oidc"
+	"golang.org/x/oauth2"
+)
+
+func TestAppleProvider(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "kid": "test-key", "use": "sig", "crv": "P-256",
+			"x": enc.EncodeToString(key.X.Bytes()),
+			"y": enc.EncodeToString(key.Y.Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewAppleProvider(ProviderConfig{
+		Name:       "apple",
+		Type:       TypeApple,
+		ClientID:   "com.pin.web",
+		TeamID:     "TEAM123456",
+		KeyID:      "test-key",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		JWKSURL:    jwks.URL,
+	}, jwks.Client())
+	if err != nil {
+		t.Fatalf("NewAppleProvider failed: %v", err)
+	}
+
+	t.Run("client secret", func(t *testing.T) {
+		cfg, err := p.configForExchange()
+		if err != nil {
+			t.Fatalf("configForExchange failed: %v", err)
+		}
+
+		// the JWKS above publishes the signing key, so we can check the
+		// secret the same way Apple would
+		v := oidc.NewVerifier(oidc.NewKeySet(jwks.URL, nil), "TEAM123456", appleIssuer)
+		claims, err := v.Verify(context.Background(), cfg.ClientSecret, "")
+		if err != nil {
+			t.Fatalf("client secret did not verify: %v", err)
+		}
+		if claims.Subject != "com.pin.web" {
+			t.Errorf("expected sub com.pin.web, got %s", claims.Subject)
+		}
+	})
+
+	nonce := "3f1c2a"
+	state := &models.OAuthState{Nonce: &nonce}
+	params := url.Values{"user": {`{"name":{"firstName":"Ada","lastName":"Lovelace"}}`}}
+
+	signed := func(t *testing.T, claims map[string]any, signer *ecdsa.PrivateKey) *oauth2.Token {
+		t.Helper()
+		idToken, err := oidc.Sign(claims, "test-key", signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (&oauth2.Token{AccessToken: "access"}).WithExtra(map[string]interface{}{"id_token": idToken})
+	}
+
+	t.Run("id token", func(t *testing.T) {
+		token := signed(t, map[string]any{
+			"iss":            appleIssuer,
+			"aud":            "com.pin.web",
+			"sub":            "001234.abcdef",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"nonce":          nonce,
+			"email":          "abc@privaterelay.appleid.com",
+			"email_verified": "true",
+		}, key)
+
+		info, err := p.UserInfo(context.Background(), token, state, params)
+		if err != nil {
+			t.Fatalf("UserInfo failed: %v", err)
+		}
+		if info.ID != "001234.abcdef" {
+			t.Errorf("expected id 001234.abcdef, got %s", info.ID)
+		}
+		if info.Email != "abc@privaterelay.appleid.com" || !info.EmailVerified {
+			t.Errorf("unexpected email %s (verified=%v)", info.Email, info.EmailVerified)
+		}
+		if info.Name != "Ada Lovelace" {
+			t.Errorf("expected name from callback params, got %q", info.Name)
+		}
+	})
+
+	t.Run("nonce from another state", func(t *testing.T) {
+		token := signed(t, map[string]any{
+			"iss":   appleIssuer,
+			"aud":   "com.pin.web",
+			"sub":   "001234.abcdef",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "someone-elses-nonce",
+		}, key)
+
+		if _, err := p.UserInfo(context.Background(), token, state, params); err == nil {
+			t.Fatal("expected nonce mismatch to be rejected")
+		}
+	})
+
+	t.Run("forged id token", func(t *testing.T) {
+		forger, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		token := signed(t, map[string]any{
+			"iss":   appleIssuer,
+			"aud":   "com.pin.web",
+			"sub":   "victim",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": nonce,
+		}, forger)
+
+		if _, err := p.UserInfo(context.Background(), token, state, params); err == nil {
+			t.Fatal("expected forged token to be rejected")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	TypeOIDC   = "oidc"
+	TypeOAuth2 = "oauth2"
+	TypeApple  = "apple"
+)
+
+// provider names end up in URLs and in oauth_accounts.provider
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ProviderConfig describes one sign in provider. Which fields matter depends
+// on Type: oidc needs Issuer, oauth2 needs the three URLs, apple uses the
+// Team/Key/PrivateKey trio to sign its client secret.
+type ProviderConfig struct {
+	Name         string
+	Type         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// oidc
+	Issuer  string
+	JWKSURL string
+
+	// oauth2
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	IDField     string
+	EmailField  string
+	NameField   string
+
+	// apple
+	TeamID     string
+	KeyID      string
+	PrivateKey string
+}
+
+func (c ProviderConfig) Validate() error {
+	if !providerNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("invalid provider name %q", c.Name)
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("%s: client ID is required", c.Name)
+	}
+
+	switch c.Type {
+	case TypeOIDC:
+		if c.Issuer == "" {
+			return fmt.Errorf("%s: issuer is required for oidc providers", c.Name)
+		}
+	case TypeOAuth2:
+		if c.AuthURL == "" || c.TokenURL == "" || c.UserInfoURL == "" {
+			return fmt.Errorf("%s: auth, token and userinfo URLs are required for oauth2 providers", c.Name)
+		}
+	case TypeApple:
+	default:
+		return fmt.Errorf("%s: unknown provider type %q", c.Name, c.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pin-app/pin/internal/models"
+	"golang.org/x/oauth2"
+)
+
+// OAuth2Provider covers providers that only speak plain OAuth 2.0, like
+// GitHub. There's no id_token, so the user is read from a JSON userinfo
+// endpoint using the configured field names.
+type OAuth2Provider struct {
+	name   string
+	oauth  *oauth2.Config
+	client *http.Client
+
+	userInfoURL string
+	idField     string
+	emailField  string
+	nameField   string
+}
+
+func NewOAuth2Provider(cfg ProviderConfig, client *http.Client) *OAuth2Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	p := &OAuth2Provider{
+		name: cfg.Name,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+		},
+		client:      client,
+		userInfoURL: cfg.UserInfoURL,
+		idField:     cfg.IDField,
+		emailField:  cfg.EmailField,
+		nameField:   cfg.NameField,
+	}
+
+	if p.idField == "" {
+		p.idField = "id"
+	}
+	if p.emailField == "" {
+		p.emailField = "email"
+	}
+	if p.nameField == "" {
+		p.nameField = "name"
+	}
+
+	return p
+}
+
+func (p *OAuth2Provider) Name() string {
+	return p.name
+}
+
+func (p *OAuth2Provider) AuthCodeURL(ctx context.Context, state *models.OAuthState) (string, error) {
+	return p.oauth.AuthCodeURL(state.State, authCodeOptions(state)...), nil
+}
+
+func (p *OAuth2Provider) Exchange(ctx context.Context, code string, state *models.OAuthState) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	return p.oauth.Exchange(ctx, code, exchangeOptions(state)...)
+}
+
+func (p *OAuth2Provider) UserInfo(ctx context.Context, token *oauth2.Token, state *models.OAuthState, params url.Values) (*UserInfo, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build userinfo request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.oauth.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch userinfo: unexpected status %d", resp.StatusCode)
+	}
+
+	var body map[string]interface{}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode userinfo: %w", err)
+	}
+
+	info := &UserInfo{
+		ID:    stringField(body, p.idField),
+		Email: stringField(body, p.emailField),
+		Name:  stringField(body, p.nameField),
+	}
+	if info.ID == "" {
+		return nil, fmt.Errorf("userinfo is missing %q", p.idField)
+	}
+
+	// plain OAuth gives no guarantee the email was verified, so it's never
+	// trusted for account matching
+	return info, nil
+}
+
+// stringField reads key as a string, accepting numeric IDs like GitHub's
+func stringField(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider signs users in with any OpenID Connect provider. Endpoints and
+// keys come from the issuer's discovery document, fetched on first use so a
+// provider being down doesn't stop the server from starting.
+type OIDCProvider struct {
+	name   string
+	cfg    ProviderConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.Verifier
+	userinfo string
+}
+
+func NewOIDCProvider(cfg ProviderConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OIDCProvider{name: cfg.Name, cfg: cfg, client: client}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.Verifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	d, err := oidc.Discover(ctx, p.cfg.Issuer, p.client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s discovery failed: %w", p.name, err)
+	}
+
+	jwksURL := d.JWKSURI
+	if p.cfg.JWKSURL != "" {
+		jwksURL = p.cfg.JWKSURL
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}
+	p.verifier = oidc.NewVerifier(oidc.NewKeySet(jwksURL, p.client), d.Issuer, p.cfg.ClientID)
+	p.userinfo = d.UserinfoEndpoint
+
+	return p.oauth, p.verifier, nil
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state *models.OAuthState) (string, error) {
+	cfg, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := append(authCodeOptions(state), oauth2.AccessTypeOffline)
+	return cfg.AuthCodeURL(state.State, opts...), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, state *models.OAuthState) (*oauth2.Token, error) {
+	cfg, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Exchange(p.withClient(ctx), code, exchangeOptions(state)...)
+}
+
+func (p *OIDCProvider) UserInfo(ctx context.Context, token *oauth2.Token, state *models.OAuthState, params url.Values) (*UserInfo, error) {
+	_, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := verifyIDToken(ctx, verifier, token, state)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}
+
+	// some providers keep the id_token lean and only hand out profile
+	// claims from the userinfo endpoint
+	if (info.Email == "" || info.Name == "") && p.userinfo != "" {
+		if err := p.fillFromUserinfo(ctx, token, info); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func (p *OIDCProvider) fillFromUserinfo(ctx context.Context, token *oauth2.Token, info *UserInfo) error {
+	cfg, _, _ := p.discover(ctx)
+
+	resp, err := cfg.Client(p.withClient(ctx), token).Get(p.userinfo)
+	if err != nil {
+		return fmt.Errorf("failed to fetch userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch userinfo: unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode userinfo: %w", err)
+	}
+
+	// userinfo has to describe the same user the id_token did
+	if body.Sub != info.ID {
+		return fmt.Errorf("userinfo subject does not match id_token")
+	}
+	if info.Email == "" {
+		info.Email = body.Email
+		info.EmailVerified = body.EmailVerified != nil && *body.EmailVerified
+	}
+	if info.Name == "" {
+		info.Name = body.Name
+	}
+
+	return nil
+}
+
+func (p *OIDCProvider) withClient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client)
+}
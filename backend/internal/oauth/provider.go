@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oidc"
+	"golang.org/x/oauth2"
+)
+
+var ErrMissingIDToken = errors.New("id_token missing from token response")
+
+// UserInfo is the provider independent view of the account that signed in
+type UserInfo struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is a single sign in provider. The state passed in carries the
+// PKCE verifier and nonce generated for this attempt.
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state *models.OAuthState) (string, error)
+	Exchange(ctx context.Context, code string, state *models.OAuthState) (*oauth2.Token, error)
+	// UserInfo maps the token response onto a UserInfo. params holds the raw
+	// callback parameters for providers that send extra data there.
+	UserInfo(ctx context.Context, token *oauth2.Token, state *models.OAuthState, params url.Values) (*UserInfo, error)
+}
+
+// authCodeOptions adds the S256 challenge and nonce to the auth URL
+func authCodeOptions(state *models.OAuthState) []oauth2.AuthCodeOption {
+	var opts []oauth2.AuthCodeOption
+	if state.CodeVerifier != nil {
+		opts = append(opts, oauth2.S256ChallengeOption(*state.CodeVerifier))
+	}
+	if state.Nonce != nil {
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", *state.Nonce))
+	}
+	return opts
+}
+
+// exchangeOptions sends the PKCE verifier with the token request. States
+// created before PKCE was added have no verifier and exchange without one.
+func exchangeOptions(state *models.OAuthState) []oauth2.AuthCodeOption {
+	if state.CodeVerifier == nil || *state.CodeVerifier == "" {
+		return nil
+	}
+	return []oauth2.AuthCodeOption{oauth2.VerifierOption(*state.CodeVerifier)}
+}
+
+// verifyIDToken checks the id_token in the token response, including the
+// nonce bound to state
+func verifyIDToken(ctx context.Context, verifier *oidc.Verifier, token *oauth2.Token, state *models.OAuthState) (*oidc.Claims, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	nonce := ""
+	if state != nil && state.Nonce != nil {
+		nonce = *state.Nonce
+	}
+
+	return verifier.Verify(ctx, rawIDToken, nonce)
+}
@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/oidc"
+	"golang.org/x/oauth2"
+)
+
+func TestOIDCProvider_Flow(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	nonce := "n-0S6_WzA2Mj"
+	state := &models.OAuthState{State: "abc", CodeVerifier: &verifier, Nonce: &nonce}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": enc.EncodeToString(key.N.Bytes()),
+			"e": enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code_verifier") != verifier {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idToken, _ := oidc.Sign(map[string]any{
+			"iss":            srv.URL,
+			"aud":            "corp-client",
+			"sub":            "employee-42",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"nonce":          nonce,
+			"email":          "ada@corp.example",
+			"email_verified": true,
+			"name":           "Ada",
+		}, "k1", key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+
+	p := NewOIDCProvider(ProviderConfig{
+		Name:     "corp",
+		Type:     TypeOIDC,
+		ClientID: "corp-client",
+		Issuer:   srv.URL,
+	}, srv.Client())
+
+	authURL, err := p.AuthCodeURL(context.Background(), state)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	sum := sha256.Sum256([]byte(verifier))
+	if got, want := q.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(sum[:]); got != want {
+		t.Errorf("expected code_challenge %s, got %s", want, got)
+	}
+	if q.Get("code_challenge_method") != "S256" {
+		t.Errorf("expected S256 challenge method, got %q", q.Get("code_challenge_method"))
+	}
+	if q.Get("nonce") != nonce {
+		t.Errorf("expected nonce %s, got %s", nonce, q.Get("nonce"))
+	}
+
+	token, err := p.Exchange(context.Background(), "code", state)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	info, err := p.UserInfo(context.Background(), token, state, nil)
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if info.ID != "employee-42" || info.Email != "ada@corp.example" || !info.EmailVerified || info.Name != "Ada" {
+		t.Errorf("unexpected user info %+v", info)
+	}
+
+	// a state minted for a different attempt carries a different nonce
+	otherNonce := "other"
+	if _, err := p.UserInfo(context.Background(), token, &models.OAuthState{Nonce: &otherNonce}, nil); err == nil {
+		t.Error("expected nonce mismatch to be rejected")
+	}
+}
+
+func TestOAuth2Provider_NumericID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": 5837261, "login": "ada", "email": "ada@example.com"}`))
+	}))
+	defer srv.Close()
+
+	p := NewOAuth2Provider(ProviderConfig{
+		Name:        "github",
+		Type:        TypeOAuth2,
+		ClientID:    "gh",
+		AuthURL:     srv.URL + "/authorize",
+		TokenURL:    srv.URL + "/token",
+		UserInfoURL: srv.URL + "/user",
+		NameField:   "login",
+	}, srv.Client())
+
+	info, err := p.UserInfo(context.Background(), &oauth2.Token{AccessToken: "at", TokenType: "Bearer"}, &models.OAuthState{}, nil)
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if info.ID != "5837261" {
+		t.Errorf("expected id 5837261, got %s", info.ID)
+	}
+	if info.Name != "ada" {
+		t.Errorf("expected name ada, got %s", info.Name)
+	}
+	if info.EmailVerified {
+		t.Error("plain OAuth emails should not be treated as verified")
+	}
+}
+
+func TestNewRegistryFromConfig(t *testing.T) {
+	registry, err := NewRegistryFromConfig([]ProviderConfig{
+		{Name: "corp", Type: TypeOIDC, ClientID: "c", Issuer: "https://sso.corp.example"},
+		{Name: "github", Type: TypeOAuth2, ClientID: "gh", AuthURL: "a", TokenURL: "t", UserInfoURL: "u"},
+		{Name: "Bad Name", Type: TypeOIDC, ClientID: "x", Issuer: "https://x"},
+		{Name: "incomplete", Type: TypeOAuth2, ClientID: "x"},
+	})
+	if err == nil {
+		t.Error("expected invalid configs to be reported")
+	}
+
+	names := registry.Names()
+	if len(names) != 2 || names[0] != "corp" || names[1] != "github" {
+		t.Errorf("expected [corp github], got %v", names)
+	}
+	if _, ok := registry.Get("incomplete"); ok {
+		t.Error("invalid provider should not be registered")
+	}
+}
@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RedirectAllowlist holds the app URLs a sign in may hand off to. A URL is
+// allowed when its scheme and host match an entry exactly and its path is
+// the entry's path or below it.
+type RedirectAllowlist []*url.URL
+
+// ParseRedirectAllowlist reads a comma separated list of URLs such as
+// "pin://auth/callback,https://pin.app/auth"
+func ParseRedirectAllowlist(raw string) (RedirectAllowlist, error) {
+	var allowlist RedirectAllowlist
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid redirect URL %q: must be a scheme, host and path", entry)
+		}
+		if (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+			return nil, fmt.Errorf("invalid redirect URL %q: missing host", entry)
+		}
+		allowlist = append(allowlist, u)
+	}
+	return allowlist, nil
+}
+
+// Allowed reports whether raw may be redirected to
+func (a RedirectAllowlist) Allowed(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil || u.Fragment != "" || u.Opaque != "" {
+		return false
+	}
+
+	for _, allowed := range a {
+		if !strings.EqualFold(u.Scheme, allowed.Scheme) || !strings.EqualFold(u.Host, allowed.Host) {
+			continue
+		}
+		// compare cleaned-up paths on segment boundaries, so /auth doesn't
+		// let /authority or /auth/../admin through
+		if strings.Contains(u.Path, "..") {
+			return false
+		}
+		base := strings.TrimSuffix(allowed.Path, "/")
+		if u.Path == allowed.Path || u.Path == base || strings.HasPrefix(u.Path, base+"/") {
+			return true
+		}
+	}
+	return false
+}
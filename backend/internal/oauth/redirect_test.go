@@ -0,0 +1,39 @@
+package oauth
+
+import "testing"
+
+func TestRedirectAllowlist(t *testing.T) {
+	allowlist, err := ParseRedirectAllowlist("pin://auth/callback, https://pin.app/auth")
+	if err != nil {
+		t.Fatalf("ParseRedirectAllowlist: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"pin://auth/callback", true},
+		{"pin://auth/callback?from=settings", true},
+		{"https://pin.app/auth", true},
+		{"https://pin.app/auth/done", true},
+		{"https://PIN.app/auth/done", true},
+		{"https://pin.app/authority", false},
+		{"https://pin.app/auth/../admin", false},
+		{"https://pin.app.evil.com/auth", false},
+		{"https://user@pin.app/auth", false},
+		{"http://pin.app/auth", false},
+		{"pin://other/callback", false},
+		{"javascript:alert(1)", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := allowlist.Allowed(tt.url); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if _, err := ParseRedirectAllowlist("https:///nohost"); err == nil {
+		t.Error("expected an entry without a host to be rejected")
+	}
+}
@@ -0,0 +1,77 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Registry holds the configured sign in providers by name
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// NewRegistryFromConfig builds a provider for each config. Bad configs are
+// reported in the returned error but don't stop the others from registering.
+func NewRegistryFromConfig(configs []ProviderConfig) (*Registry, error) {
+	registry := NewRegistry()
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var errs []error
+	for _, cfg := range configs {
+		if err := cfg.Validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var provider Provider
+		switch cfg.Type {
+		case TypeOIDC:
+			provider = NewOIDCProvider(cfg, client)
+		case TypeOAuth2:
+			provider = NewOAuth2Provider(cfg, client)
+		case TypeApple:
+			apple, err := NewAppleProvider(cfg, client)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", cfg.Name, err))
+				continue
+			}
+			provider = apple
+		}
+
+		if err := registry.Register(provider); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return registry, errors.Join(errs...)
+}
+
+func (r *Registry) Register(p Provider) error {
+	if _, exists := r.providers[p.Name()]; exists {
+		return fmt.Errorf("provider %q registered twice", p.Name())
+	}
+	r.providers[p.Name()] = p
+	return nil
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names lists the registered providers in a stable order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
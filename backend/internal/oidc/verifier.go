@@ -0,0 +1,135 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTokenExpired    = errors.New("token expired")
+	ErrInvalidIssuer   = errors.New("invalid token issuer")
+	ErrInvalidAudience = errors.New("invalid token audience")
+	ErrNonceMismatch   = errors.New("token nonce mismatch")
+)
+
+// clock skew we tolerate between us and the provider
+const leeway = time.Minute
+
+// Claims are the ID token claims the app cares about. Raw keeps everything
+// else for provider specific mapping.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Audience      []string
+	Expiry        time.Time
+	IssuedAt      time.Time
+	Nonce         string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Raw           map[string]any
+}
+
+// Verifier checks ID tokens issued by a single provider
+type Verifier struct {
+	keys      *KeySet
+	issuer    string
+	audiences []string
+	now       func() time.Time
+}
+
+func NewVerifier(keys *KeySet, issuer string, audiences ...string) *Verifier {
+	return &Verifier{
+		keys:      keys,
+		issuer:    issuer,
+		audiences: audiences,
+		now:       time.Now,
+	}
+}
+
+// Verify checks the signature, issuer, audience and expiry of rawToken. When
+// nonce is non-empty the token must carry the same value.
+func (v *Verifier) Verify(ctx context.Context, rawToken, nonce string) (*Claims, error) {
+	t, err := parseToken(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := v.keys.Key(ctx, t.header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verify(key); err != nil {
+		return nil, err
+	}
+
+	claims := claimsFromMap(t.claims)
+
+	if claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidIssuer, claims.Issuer)
+	}
+	if !v.audienceMatches(claims.Audience) {
+		return nil, ErrInvalidAudience
+	}
+	if claims.Expiry.IsZero() || v.now().After(claims.Expiry.Add(leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrMalformedToken)
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) audienceMatches(aud []string) bool {
+	for _, want := range v.audiences {
+		for _, got := range aud {
+			if got == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func claimsFromMap(m map[string]any) *Claims {
+	c := &Claims{Raw: m}
+	c.Issuer, _ = m["iss"].(string)
+	c.Subject, _ = m["sub"].(string)
+	c.Nonce, _ = m["nonce"].(string)
+	c.Email, _ = m["email"].(string)
+	c.Name, _ = m["name"].(string)
+
+	switch aud := m["aud"].(type) {
+	case string:
+		c.Audience = []string{aud}
+	case []any:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				c.Audience = append(c.Audience, s)
+			}
+		}
+	}
+
+	if exp, ok := m["exp"].(float64); ok {
+		c.Expiry = time.Unix(int64(exp), 0)
+	}
+	if iat, ok := m["iat"].(float64); ok {
+		c.IssuedAt = time.Unix(int64(iat), 0)
+	}
+
+	// Apple sends email_verified as the string "true"
+	switch ev := m["email_verified"].(type) {
+	case bool:
+		c.EmailVerified = ev
+	case string:
+		c.EmailVerified = ev == "true"
+	}
+
+	return c
+}
@@ -0,0 +1,124 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testClientID = "com.pin.app"
+)
+
+func newTestJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	keys := []jsonWebKey{
+		{Kty: "RSA", Kid: "rsa-1", Use: "sig", N: b64(rsaKey.N), E: b64(big.NewInt(int64(rsaKey.E)))},
+		{Kty: "EC", Kid: "ec-1", Crv: "P-256", X: b64(ecKey.X), Y: b64(ecKey.Y)},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":            testIssuer,
+		"aud":            testClientID,
+		"sub":            "001234.abcdef",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "n-0S6_WzA2Mj",
+		"email":          "user@privaterelay.appleid.com",
+		"email_verified": "true",
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newTestJWKS(t, rsaKey, ecKey)
+	verifier := NewVerifier(NewKeySet(srv.URL, srv.Client()), testIssuer, testClientID)
+
+	tests := []struct {
+		name    string
+		mutate  func(map[string]any)
+		kid     string
+		key     interface{}
+		nonce   string
+		wantErr error
+	}{
+		{name: "valid RS256", kid: "rsa-1", key: rsaKey, nonce: "n-0S6_WzA2Mj"},
+		{name: "valid ES256", kid: "ec-1", key: ecKey},
+		{name: "wrong signer", kid: "rsa-1", key: otherKey, wantErr: ErrInvalidSignature},
+		{name: "unknown kid", kid: "missing", key: rsaKey, wantErr: ErrUnknownKey},
+		{name: "wrong issuer", kid: "rsa-1", key: rsaKey, mutate: func(c map[string]any) { c["iss"] = "https://evil.example.com" }, wantErr: ErrInvalidIssuer},
+		{name: "wrong audience", kid: "rsa-1", key: rsaKey, mutate: func(c map[string]any) { c["aud"] = []string{"someone.else"} }, wantErr: ErrInvalidAudience},
+		{name: "expired", kid: "rsa-1", key: rsaKey, mutate: func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: ErrTokenExpired},
+		{name: "nonce mismatch", kid: "rsa-1", key: rsaKey, nonce: "other", wantErr: ErrNonceMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+
+			var raw string
+			switch k := tt.key.(type) {
+			case *rsa.PrivateKey:
+				raw, err = Sign(claims, tt.kid, k)
+			case *ecdsa.PrivateKey:
+				raw, err = Sign(claims, tt.kid, k)
+			}
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+
+			got, err := verifier.Verify(context.Background(), raw, tt.nonce)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if got.Subject != "001234.abcdef" {
+				t.Errorf("expected subject 001234.abcdef, got %s", got.Subject)
+			}
+			if !got.EmailVerified {
+				t.Error("expected email_verified to be true")
+			}
+		})
+	}
+}
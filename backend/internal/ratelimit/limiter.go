@@ -0,0 +1,155 @@
+// Package ratelimit throttles requests with token buckets. Each route group
+// has its own Policy, and every request takes a token from its client IP's
+// bucket and, when signed in, its user's, both scoped to the group and
+// route.
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pin-app/pin/internal/middleware"
+	"github.com/pin-app/pin/internal/server"
+)
+
+// Policy allows Limit requests per Per on average, with bursts of up to
+// Burst (Limit when unset)
+type Policy struct {
+	Limit int
+	Per   time.Duration
+	Burst int
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Per.Seconds()
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Route groups and their default policies
+const (
+	// GroupAuth covers starting and finishing sign in
+	GroupAuth = "auth"
+	// GroupAuthToken covers endpoints that take a secret: refresh tokens,
+	// exchange codes and magic link requests
+	GroupAuthToken = "auth_token"
+	// GroupSignup covers creating users
+	GroupSignup = "signup"
+	// GroupExport covers requesting a data export
+	GroupExport = "export"
+)
+
+var DefaultPolicies = map[string]Policy{
+	GroupAuth:      {Limit: 30, Per: time.Minute},
+	GroupAuthToken: {Limit: 10, Per: time.Minute},
+	GroupSignup:    {Limit: 5, Per: time.Hour},
+	GroupExport:    {Limit: 3, Per: 24 * time.Hour},
+}
+
+// ParsePolicy reads limit/duration[:burst]
+func ParsePolicy(raw string) (Policy, error) {
+	rate, burstStr, hasBurst := strings.Cut(strings.TrimSpace(raw), ":")
+	limitStr, perStr, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid policy %q, want limit/duration[:burst]", raw)
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid limit %q", limitStr)
+	}
+	per, err := time.ParseDuration(perStr)
+	if err != nil || per <= 0 {
+		return Policy{}, fmt.Errorf("invalid duration %q", perStr)
+	}
+
+	policy := Policy{Limit: limit, Per: per}
+	if hasBurst {
+		if policy.Burst, err = strconv.Atoi(burstStr); err != nil || policy.Burst <= 0 {
+			return Policy{}, fmt.Errorf("invalid burst %q", burstStr)
+		}
+	}
+	return policy, nil
+}
+
+// Limiter applies policies to route groups
+type Limiter struct {
+	store    Store
+	policies map[string]Policy
+}
+
+func New(store Store, policies map[string]Policy) *Limiter {
+	return &Limiter{store: store, policies: policies}
+}
+
+// Middleware is Limit for a route group's shared middleware
+func (l *Limiter) Middleware(group string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return l.Limit(group, next)
+	}
+}
+
+// Limit throttles next under the group's policy, answering 429 with a
+// Retry-After header once a bucket runs dry. Wrap it inside RequireAuth to
+// also key on the user. Groups without a policy aren't limited.
+func (l *Limiter) Limit(group string, next http.HandlerFunc) http.HandlerFunc {
+	policy, ok := l.policies[group]
+	if !ok {
+		slog.Warn("no rate limit policy for group", "group", group)
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// key on the route pattern so every /api/users/{id} shares a
+		// bucket, rather than each ID getting a fresh one
+		pattern := r.Pattern
+		if pattern == "" {
+			pattern = r.URL.Path
+		}
+		route := r.Method + " " + pattern
+		ip := middleware.ClientIP(r)
+
+		keys := []string{group + "|" + route + "|ip:" + ip}
+		userID, signedIn := middleware.GetUserIDFromContext(r.Context())
+		if signedIn {
+			keys = append(keys, group+"|"+route+"|user:"+userID.String())
+		}
+
+		for _, key := range keys {
+			result, err := l.store.Take(r.Context(), key, policy)
+			if err != nil {
+				// an outage in a shared store shouldn't take the API down with it
+				slog.Error("rate limit store failed, letting request through", "error", err, "group", group)
+				continue
+			}
+			if result.Allowed {
+				continue
+			}
+
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if result.LockedOut {
+				attrs := []any{"group", group, "route", route, "ip", ip, "retry_after_s", retryAfter}
+				if signedIn {
+					attrs = append(attrs, "user_id", userID)
+				}
+				slog.Warn("rate limit lockout", attrs...)
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			server.WriteJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Too many requests, try again later"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
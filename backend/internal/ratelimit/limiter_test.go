@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/middleware"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Policy
+		wantErr bool
+	}{
+		{"10/1m", Policy{Limit: 10, Per: time.Minute}, false},
+		{"5/1h:20", Policy{Limit: 5, Per: time.Hour, Burst: 20}, false},
+		{"10", Policy{}, true},
+		{"0/1m", Policy{}, true},
+		{"10/forever", Policy{}, true},
+		{"10/1m:x", Policy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParsePolicy(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	limiter := New(NewMemoryStore(), map[string]Policy{"test": {Limit: 1, Per: time.Minute}})
+	handler := limiter.Limit("test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/refresh", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	if w := request("203.0.113.1:1000"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+
+	w := request("203.0.113.1:2000")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body["error"] == "" {
+		t.Errorf("body isn't a JSON error: %v", err)
+	}
+
+	if w := request("203.0.113.2:1000"); w.Code != http.StatusOK {
+		t.Errorf("another IP: status %d", w.Code)
+	}
+}
+
+func TestLimitKeysOnUser(t *testing.T) {
+	limiter := New(NewMemoryStore(), map[string]Policy{"test": {Limit: 1, Per: time.Minute}})
+	handler := limiter.Limit("test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	userID := uuid.New()
+	request := func(remoteAddr string) int {
+		req := httptest.NewRequest("POST", "/api/auth/google/link", nil)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	if code := request("203.0.113.1:1000"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	// a new address doesn't get the same user a fresh bucket
+	if code := request("203.0.113.2:1000"); code != http.StatusTooManyRequests {
+		t.Errorf("same user from another IP: status %d, want 429", code)
+	}
+}
+
+func TestLimitKeysOnPattern(t *testing.T) {
+	limiter := New(NewMemoryStore(), map[string]Policy{"test": {Limit: 1, Per: time.Minute}})
+	handler := limiter.Limit("test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(path string) int {
+		req := httptest.NewRequest("POST", path, nil)
+		req.Pattern = "/api/users/{id}/follow"
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	if code := request("/api/users/" + uuid.NewString() + "/follow"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	// another ID on the same route doesn't get a fresh bucket
+	if code := request("/api/users/" + uuid.NewString() + "/follow"); code != http.StatusTooManyRequests {
+		t.Errorf("another ID: status %d, want 429", code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	return Result{}, errors.New("store down")
+}
+
+func TestLimitFailsOpen(t *testing.T) {
+	limiter := New(failingStore{}, map[string]Policy{"test": {Limit: 1, Per: time.Minute}})
+	handler := limiter.Limit("test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/users", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status %d, want 200 when the store is down", w.Code)
+	}
+}
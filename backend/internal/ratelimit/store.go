@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long until a token is available, when not Allowed
+	RetryAfter time.Duration
+	// LockedOut is set on the first refusal after a run of allowed requests,
+	// so a lockout is reported once rather than on every rejected request
+	LockedOut bool
+}
+
+// Store keeps token buckets. MemoryStore is enough for a single instance;
+// running several behind a load balancer needs a shared implementation
+// (Redis, Postgres) so they agree on how many tokens are left.
+type Store interface {
+	// Take refills the bucket at key according to policy, then removes a
+	// token from it if there is one
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// how often MemoryStore drops buckets that have refilled completely
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limited bool
+	full    time.Time // when the bucket will be full again, for sweeping
+}
+
+// MemoryStore keeps buckets in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	burst := float64(policy.burst())
+	rate := policy.rate()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.limited = false
+		b.full = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+		return Result{Allowed: true}, nil
+	}
+
+	lockedOut := !b.limited
+	b.limited = true
+	b.full = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+
+	return Result{
+		RetryAfter: time.Duration((1 - b.tokens) / rate * float64(time.Second)),
+		LockedOut:  lockedOut,
+	}, nil
+}
+
+// sweep forgets buckets that are full again, since a fresh bucket behaves
+// the same. Called with the lock held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	policy := Policy{Limit: 2, Per: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take(ctx, "k", policy); !result.Allowed {
+			t.Fatalf("request %d refused", i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "k", policy)
+	if result.Allowed || !result.LockedOut {
+		t.Fatalf("expected first refusal to lock out, got %+v", result)
+	}
+	if result.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", result.RetryAfter)
+	}
+
+	result, _ = store.Take(ctx, "k", policy)
+	if result.Allowed || result.LockedOut {
+		t.Errorf("expected a quiet refusal, got %+v", result)
+	}
+
+	if result, _ := store.Take(ctx, "other", policy); !result.Allowed {
+		t.Error("buckets should be independent")
+	}
+
+	now = now.Add(30 * time.Second)
+	if result, _ := store.Take(ctx, "k", policy); !result.Allowed {
+		t.Error("expected a token after refilling")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	policy := Policy{Limit: 1, Per: time.Second}
+	store.Take(context.Background(), "k", policy)
+
+	now = now.Add(2 * sweepInterval)
+	store.Take(context.Background(), "other", policy)
+
+	if _, ok := store.buckets["k"]; ok {
+		t.Error("expected the refilled bucket to be swept")
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+)
+
+type accessTokenRepository struct {
+	db *database.DB
+}
+
+func NewAccessTokenRepository(db *database.DB) AccessTokenRepository {
+	return &accessTokenRepository{db: db}
+}
+
+func (r *accessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	query := `
+		INSERT INTO access_tokens (id, user_id, name, scopes, token_hash, token_hint, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.GetConnection().ExecContext(ctx, query,
+		token.ID, token.UserID, token.Name, pq.Array(scopeStrings(token.Scopes)),
+		hashToken(token.Token), token.TokenHint, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByToken looks up an unexpired, unrevoked token
+func (r *accessTokenRepository) GetByToken(ctx context.Context, token string) (*models.AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, token_hint, last_used_at, expires_at, created_at, deleted_at
+		FROM access_tokens
+		WHERE token_hash = $1 AND expires_at > NOW() AND deleted_at IS NULL
+	`
+
+	accessToken, err := scanAccessToken(r.db.GetConnection().QueryRowContext(ctx, query, hashToken(token)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAccessTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return accessToken, nil
+}
+
+// ListByUserID returns the user's tokens that haven't been revoked, expired
+// ones included so they show up as needing replacement
+func (r *accessTokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, token_hint, last_used_at, expires_at, created_at, deleted_at
+		FROM access_tokens
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.AccessToken
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeleteForUser revokes a token, only if it belongs to userID
+func (r *accessTokenRepository) DeleteForUser(ctx context.Context, id, userID uuid.UUID) error {
+	query := `
+		UPDATE access_tokens
+		SET deleted_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+func (r *accessTokenRepository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE access_tokens SET last_used_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, id, usedAt); err != nil {
+		return fmt.Errorf("failed to touch access token: %w", err)
+	}
+
+	return nil
+}
+
+func scanAccessToken(row interface{ Scan(...any) error }) (*models.AccessToken, error) {
+	token := &models.AccessToken{}
+	var scopes []string
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, pq.Array(&scopes), &token.TokenHint,
+		&token.LastUsedAt, &token.ExpiresAt, &token.CreatedAt, &token.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = make([]models.Scope, len(scopes))
+	for i, scope := range scopes {
+		token.Scopes[i] = models.Scope(scope)
+	}
+
+	return token, nil
+}
+
+func scopeStrings(scopes []models.Scope) []string {
+	out := make([]string, len(scopes))
+	for i, scope := range scopes {
+		out[i] = string(scope)
+	}
+	return out
+}
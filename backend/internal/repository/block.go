@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+)
+
+type blockRepository struct {
+	db *database.DB
+}
+
+func NewBlockRepository(db *database.DB) BlockRepository {
+	return &blockRepository{db: db}
+}
+
+func (r *blockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	return r.db.WithTx(func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO user_blocks (blocker_id, blocked_id)
+			VALUES ($1, $2)
+			ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return fmt.Errorf("failed to block user: %w", err)
+		}
+
+		// neither side keeps following the other, pending requests included
+		query = `
+			DELETE FROM follows
+			WHERE (follower_id = $1 AND following_id = $2) OR (follower_id = $2 AND following_id = $1)
+		`
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return fmt.Errorf("failed to remove follows: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	query := `DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`
+	result, err := r.db.GetConnection().ExecContext(ctx, query, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrBlockNotFound
+	}
+
+	return nil
+}
+
+func (r *blockRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	query := `SELECT ` + blockedSQL("$1::uuid", "$2::uuid")
+	var blocked bool
+	if err := r.db.GetConnection().QueryRowContext(ctx, query, userID, otherID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+	return blocked, nil
+}
+
+func (r *blockRepository) ListBlocked(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.is_private, u.created_at, u.updated_at
+		FROM user_blocks b
+		JOIN users u ON b.blocked_id = u.id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.listUsers(ctx, query, userID, limit, offset)
+}
+
+func (r *blockRepository) Mute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	query := `
+		INSERT INTO user_mutes (muter_id, muted_id)
+		VALUES ($1, $2)
+		ON CONFLICT (muter_id, muted_id) DO NOTHING
+	`
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, muterID, mutedID); err != nil {
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	query := `DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2`
+	result, err := r.db.GetConnection().ExecContext(ctx, query, muterID, mutedID)
+	if err != nil {
+		return fmt.Errorf("failed to unmute user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrMuteNotFound
+	}
+
+	return nil
+}
+
+func (r *blockRepository) ListMuted(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.is_private, u.created_at, u.updated_at
+		FROM user_mutes m
+		JOIN users u ON m.muted_id = u.id
+		WHERE m.muter_id = $1
+		ORDER BY m.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.listUsers(ctx, query, userID, limit, offset)
+}
+
+func (r *blockRepository) listUsers(ctx context.Context, query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		err := rows.Scan(
+			&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
+			&user.DisplayName, &user.PfpURL, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
@@ -18,13 +18,17 @@ func NewCommentRepository(db *database.DB) CommentRepository {
 	return &commentRepository{db: db}
 }
 
+// Create adds the comment, or fails with ErrBlocked if the post's author or
+// the author of the comment being replied to has a block with the commenter
 func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
 	query := `
 		INSERT INTO comments (id, post_id, user_id, parent_id, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4::uuid, $5::text, $6::timestamptz, $7::timestamptz
+		WHERE NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = $2 AND ` + blockedSQL("p.user_id", "$3") + `)
+		AND NOT EXISTS (SELECT 1 FROM comments pc WHERE pc.id = $4 AND ` + blockedSQL("pc.user_id", "$3") + `)
 	`
 
-	_, err := r.db.GetConnection().ExecContext(ctx, query,
+	result, err := r.db.GetConnection().ExecContext(ctx, query,
 		comment.ID, comment.PostID, comment.UserID, comment.ParentID, comment.Content,
 		comment.CreatedAt, comment.UpdatedAt,
 	)
@@ -33,6 +37,15 @@ func (r *commentRepository) Create(ctx context.Context, comment *models.Comment)
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrBlocked
+	}
+
 	return nil
 }
 
@@ -59,6 +72,30 @@ func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 	return comment, nil
 }
 
+func (r *commentRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Comment, error) {
+	query := `
+		SELECT id, post_id, user_id, parent_id, path, content, created_at, updated_at, deleted_at
+		FROM comments
+		WHERE id = $1 AND deleted_at IS NULL AND NOT ` + blockedSQL("user_id", "$2") + `
+		AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND ` + canViewPostSQL("p", "$2") + `)
+	`
+
+	comment := &models.Comment{}
+	err := r.db.GetConnection().QueryRowContext(ctx, query, id, viewerID).Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.ParentID, &comment.Path,
+		&comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &comment.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment by ID: %w", err)
+	}
+
+	return comment, nil
+}
+
 func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
 	query := `
 		UPDATE comments
@@ -86,14 +123,17 @@ func (r *commentRepository) Update(ctx context.Context, comment *models.Comment)
 	return nil
 }
 
-func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *commentRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
 	query := `
 		UPDATE comments
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
+		AND (user_id = $2 OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND role IN ('moderator', 'admin') AND deleted_at IS NULL
+		))
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query, id)
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, actorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete comment: %w", err)
 	}
@@ -104,22 +144,23 @@ func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("comment not found or already deleted")
+		return fmt.Errorf("comment not found, not owned by actor, or already deleted")
 	}
 
 	return nil
 }
 
-func (r *commentRepository) ListByPostID(ctx context.Context, postID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+func (r *commentRepository) ListByPostID(ctx context.Context, postID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
 	query := `
 		SELECT id, post_id, user_id, parent_id, path, content, created_at, updated_at, deleted_at
 		FROM comments
-		WHERE post_id = $1 AND deleted_at IS NULL
+		WHERE post_id = $1 AND deleted_at IS NULL AND NOT ` + blockedSQL("user_id", "$2") + `
+		AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND ` + canViewPostSQL("p", "$2") + `)
 		ORDER BY path
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, postID, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, postID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list comments by post ID: %w", err)
 	}
@@ -145,16 +186,20 @@ func (r *commentRepository) ListByPostID(ctx context.Context, postID uuid.UUID,
 	return comments, nil
 }
 
-func (r *commentRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+// ListByUserID lists the comments userID wrote on posts viewerID can see.
+// Authors always see all of their own, which the data export relies on.
+func (r *commentRepository) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
 	query := `
 		SELECT id, post_id, user_id, parent_id, path, content, created_at, updated_at, deleted_at
 		FROM comments
 		WHERE user_id = $1 AND deleted_at IS NULL
+		AND (user_id = $2 OR (NOT ` + blockedSQL("user_id", "$2") + `
+			AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND ` + canViewPostSQL("p", "$2") + `)))
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list comments by user ID: %w", err)
 	}
@@ -180,16 +225,17 @@ func (r *commentRepository) ListByUserID(ctx context.Context, userID uuid.UUID,
 	return comments, nil
 }
 
-func (r *commentRepository) GetReplies(ctx context.Context, parentID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+func (r *commentRepository) GetReplies(ctx context.Context, parentID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
 	query := `
 		SELECT id, post_id, user_id, parent_id, path, content, created_at, updated_at, deleted_at
 		FROM comments
-		WHERE parent_id = $1 AND deleted_at IS NULL
+		WHERE parent_id = $1 AND deleted_at IS NULL AND NOT ` + blockedSQL("user_id", "$2") + `
+		AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND ` + canViewPostSQL("p", "$2") + `)
 		ORDER BY created_at ASC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, parentID, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, parentID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comment replies: %w", err)
 	}
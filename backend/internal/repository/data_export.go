@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+)
+
+const dataExportColumns = `id, user_id, status, size_bytes, error, expires_at, started_at, completed_at, created_at`
+
+type dataExportRepository struct {
+	db *database.DB
+}
+
+func NewDataExportRepository(db *database.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDataExport(row rowScanner) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	err := row.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.SizeBytes, &export.Error,
+		&export.ExpiresAt, &export.StartedAt, &export.CompletedAt, &export.CreatedAt,
+	)
+	return export, err
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	query := `
+		INSERT INTO data_exports (id, user_id, status, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if export.Status == "" {
+		export.Status = models.DataExportPending
+	}
+
+	_, err := r.db.GetConnection().ExecContext(ctx, query, export.ID, export.UserID, export.Status, export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE id = $1`
+
+	export, err := scanDataExport(r.db.GetConnection().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.list(ctx, query, userID, limit, offset)
+}
+
+func (r *dataExportRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE user_id = $1 AND status IN ('pending', 'running')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	export, err := scanDataExport(r.db.GetConnection().QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get active data export: %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) ClaimPending(ctx context.Context, staleBefore time.Time) (*models.DataExport, error) {
+	query := `
+		UPDATE data_exports
+		SET status = 'running', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + dataExportColumns
+
+	export, err := scanDataExport(r.db.GetConnection().QueryRowContext(ctx, query, staleBefore))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) MarkReady(ctx context.Context, id uuid.UUID, sizeBytes int64, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'ready', size_bytes = $2, expires_at = $3, completed_at = NOW(), error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, id, sizeBytes, expiresAt); err != nil {
+		return fmt.Errorf("failed to mark data export ready: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', error = $2, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark data export failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) ExpireReady(ctx context.Context, now time.Time) ([]*models.DataExport, error) {
+	query := `
+		UPDATE data_exports
+		SET status = 'expired'
+		WHERE status = 'ready' AND expires_at <= $1
+		RETURNING ` + dataExportColumns
+
+	return r.list(ctx, query, now)
+}
+
+func (r *dataExportRepository) list(ctx context.Context, query string, args ...any) ([]*models.DataExport, error) {
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*models.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate data exports: %w", err)
+	}
+
+	return exports, nil
+}
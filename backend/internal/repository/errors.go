@@ -4,6 +4,8 @@ import "errors"
 
 var (
 	ErrUserNotFound         = errors.New("user not found")
+	ErrLastAdmin            = errors.New("cannot remove the last admin")
+	ErrNoDeletionPending    = errors.New("no account deletion pending")
 	ErrPlaceNotFound        = errors.New("place not found")
 	ErrPostNotFound         = errors.New("post not found")
 	ErrCommentNotFound      = errors.New("comment not found")
@@ -11,5 +13,17 @@ var (
 	ErrComparisonNotFound   = errors.New("comparison not found")
 	ErrOAuthAccountNotFound = errors.New("OAuth account not found")
 	ErrOAuthStateNotFound   = errors.New("OAuth state not found")
+	ErrLastOAuthAccount     = errors.New("cannot remove the last linked OAuth account")
 	ErrSessionNotFound      = errors.New("session not found")
+	ErrRefreshTokenReused   = errors.New("refresh token already used")
+	ErrAccessTokenNotFound  = errors.New("access token not found")
+	ErrMagicLinkNotFound    = errors.New("magic link not found or already used")
+	ErrExchangeCodeNotFound = errors.New("exchange code not found or already used")
+	ErrDataExportNotFound   = errors.New("data export not found")
+	ErrFollowNotFound       = errors.New("follow relationship not found")
+	ErrBlockNotFound        = errors.New("block not found")
+	ErrMuteNotFound         = errors.New("mute not found")
+	// ErrBlocked is returned when one of the users involved has blocked the
+	// other, so a follow, like, comment or notification can't be created
+	ErrBlocked = errors.New("blocked")
 )
@@ -14,8 +14,11 @@ type FollowRepository interface {
 	CreateFollow(ctx context.Context, follow *models.Follow) error
 	DeleteFollow(ctx context.Context, followerID, followingID uuid.UUID) error
 	GetFollow(ctx context.Context, followerID, followingID uuid.UUID) (*models.Follow, error)
-	ListFollowing(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error)
-	ListFollowers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error)
+	ListFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error)
+	ListFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error)
+	ListFollowRequests(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error)
+	AcceptFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error
+	DeleteFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error
 	GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error)
 	GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error)
 	IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
@@ -31,14 +34,33 @@ func NewFollowRepository(db *database.DB) FollowRepository {
 }
 
 func (r *followRepository) CreateFollow(ctx context.Context, follow *models.Follow) error {
+	if follow.Status == "" {
+		follow.Status = models.FollowAccepted
+	}
+
 	query := `
-		INSERT INTO follows (id, follower_id, following_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO follows (id, follower_id, following_id, status, created_at, updated_at)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4::text, $5::timestamptz, $6::timestamptz
+		WHERE NOT ` + blockedSQL("$2::uuid", "$3::uuid") + `
 	`
-	_, err := r.db.GetConnection().ExecContext(ctx, query, follow.ID, follow.FollowerID, follow.FollowingID, follow.CreatedAt, follow.UpdatedAt)
-	return err
+	result, err := r.db.GetConnection().ExecContext(ctx, query, follow.ID, follow.FollowerID, follow.FollowingID, follow.Status, follow.CreatedAt, follow.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrBlocked
+	}
+
+	return nil
 }
 
+// DeleteFollow removes a follow or a pending follow request
 func (r *followRepository) DeleteFollow(ctx context.Context, followerID, followingID uuid.UUID) error {
 	query := `DELETE FROM follows WHERE follower_id = $1 AND following_id = $2`
 	result, err := r.db.GetConnection().ExecContext(ctx, query, followerID, followingID)
@@ -52,15 +74,17 @@ func (r *followRepository) DeleteFollow(ctx context.Context, followerID, followi
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("follow relationship not found")
+		return ErrFollowNotFound
 	}
 
 	return nil
 }
 
+// GetFollow returns the follow or pending request from followerID to
+// followingID
 func (r *followRepository) GetFollow(ctx context.Context, followerID, followingID uuid.UUID) (*models.Follow, error) {
 	query := `
-		SELECT id, follower_id, following_id, created_at, updated_at
+		SELECT id, follower_id, following_id, status, created_at, updated_at
 		FROM follows
 		WHERE follower_id = $1 AND following_id = $2
 	`
@@ -70,13 +94,14 @@ func (r *followRepository) GetFollow(ctx context.Context, followerID, followingI
 		&follow.ID,
 		&follow.FollowerID,
 		&follow.FollowingID,
+		&follow.Status,
 		&follow.CreatedAt,
 		&follow.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("follow relationship not found")
+			return nil, ErrFollowNotFound
 		}
 		return nil, err
 	}
@@ -84,56 +109,102 @@ func (r *followRepository) GetFollow(ctx context.Context, followerID, followingI
 	return &follow, nil
 }
 
-func (r *followRepository) ListFollowing(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+// ListFollowing lists who userID follows, or nothing when userID is private
+// and viewerID isn't one of their approved followers
+func (r *followRepository) ListFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	query := `
-		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.created_at, u.updated_at
+		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.is_private, u.created_at, u.updated_at
 		FROM follows f
 		JOIN users u ON f.following_id = u.id
-		WHERE f.follower_id = $1
+		WHERE f.follower_id = $1 AND f.status = 'accepted' AND ` + canViewOwnerSQL("f.follower_id", "$2") + `
+		AND NOT ` + blockedSQL("u.id", "$2") + `
 		ORDER BY f.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	return r.listUsers(ctx, query, userID, viewerID, limit, offset)
+}
+
+// ListFollowers lists userID's approved followers, or nothing when userID is
+// private and viewerID isn't one of them
+func (r *followRepository) ListFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.is_private, u.created_at, u.updated_at
+		FROM follows f
+		JOIN users u ON f.follower_id = u.id
+		WHERE f.following_id = $1 AND f.status = 'accepted' AND ` + canViewOwnerSQL("f.following_id", "$2") + `
+		AND NOT ` + blockedSQL("u.id", "$2") + `
+		ORDER BY f.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	return r.listUsers(ctx, query, userID, viewerID, limit, offset)
+}
+
+// ListFollowRequests lists the users waiting for userID to approve their
+// follow request, oldest first
+func (r *followRepository) ListFollowRequests(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.is_private, u.created_at, u.updated_at
+		FROM follows f
+		JOIN users u ON f.follower_id = u.id
+		WHERE f.following_id = $1 AND f.status = 'pending'
+		ORDER BY f.created_at ASC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, limit, offset)
+	return r.listUsers(ctx, query, userID, limit, offset)
+}
+
+// AcceptFollowRequest turns a pending request from followerID into a follow
+func (r *followRepository) AcceptFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error {
+	query := `
+		UPDATE follows SET status = 'accepted', updated_at = NOW()
+		WHERE follower_id = $1 AND following_id = $2 AND status = 'pending'
+	`
+	result, err := r.db.GetConnection().ExecContext(ctx, query, followerID, followingID)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to accept follow request: %w", err)
 	}
-	defer rows.Close()
 
-	var users []*models.User
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Username,
-			&user.Bio,
-			&user.Location,
-			&user.DisplayName,
-			&user.PfpURL,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, &user)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	return users, nil
+	if rowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+
+	return nil
 }
 
-func (r *followRepository) ListFollowers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error) {
+// DeleteFollowRequest drops a pending request from followerID. An approved
+// follow is left alone.
+func (r *followRepository) DeleteFollowRequest(ctx context.Context, followerID, followingID uuid.UUID) error {
 	query := `
-		SELECT u.id, u.email, u.username, u.bio, u.location, u.display_name, u.pfp_url, u.created_at, u.updated_at
-		FROM follows f
-		JOIN users u ON f.follower_id = u.id
-		WHERE f.following_id = $1
-		ORDER BY f.created_at DESC
-		LIMIT $2 OFFSET $3
+		DELETE FROM follows
+		WHERE follower_id = $1 AND following_id = $2 AND status = 'pending'
 	`
+	result, err := r.db.GetConnection().ExecContext(ctx, query, followerID, followingID)
+	if err != nil {
+		return fmt.Errorf("failed to delete follow request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+
+	return nil
+}
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, limit, offset)
+func (r *followRepository) listUsers(ctx context.Context, query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +221,7 @@ func (r *followRepository) ListFollowers(ctx context.Context, userID uuid.UUID,
 			&user.Location,
 			&user.DisplayName,
 			&user.PfpURL,
+			&user.IsPrivate,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -159,25 +231,25 @@ func (r *followRepository) ListFollowers(ctx context.Context, userID uuid.UUID,
 		users = append(users, &user)
 	}
 
-	return users, nil
+	return users, rows.Err()
 }
 
 func (r *followRepository) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
-	query := `SELECT COUNT(*) FROM follows WHERE follower_id = $1`
+	query := `SELECT COUNT(*) FROM follows WHERE follower_id = $1 AND status = 'accepted'`
 	var count int
 	err := r.db.GetConnection().QueryRowContext(ctx, query, userID).Scan(&count)
 	return count, err
 }
 
 func (r *followRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
-	query := `SELECT COUNT(*) FROM follows WHERE following_id = $1`
+	query := `SELECT COUNT(*) FROM follows WHERE following_id = $1 AND status = 'accepted'`
 	var count int
 	err := r.db.GetConnection().QueryRowContext(ctx, query, userID).Scan(&count)
 	return count, err
 }
 
 func (r *followRepository) IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = $2)`
+	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = $2 AND status = 'accepted')`
 	var exists bool
 	err := r.db.GetConnection().QueryRowContext(ctx, query, followerID, followingID).Scan(&exists)
 	return exists, err
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pin-app/pin/internal/models"
@@ -11,12 +12,28 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetVisibleByID is GetByID for showing a profile to viewerID; it's
+	// ErrUserNotFound if either has blocked the other
+	GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]*models.User, error)
-	Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, role models.UserRole) error
+	// Delete soft-deletes the user; actorID must be the user themselves or an admin
+	Delete(ctx context.Context, id, actorID uuid.UUID) error
+	List(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*models.User, error)
+	Search(ctx context.Context, query string, viewerID uuid.UUID, limit, offset int) ([]*models.User, error)
+
+	// ScheduleDeletion marks the account for erasing at the given time, or
+	// keeps the earlier time if it's already scheduled. Same actor rules as
+	// Delete. Returns ErrLastAdmin when every other admin is gone or going.
+	ScheduleDeletion(ctx context.Context, id, actorID uuid.UUID, at time.Time) (time.Time, error)
+	CancelDeletion(ctx context.Context, id, actorID uuid.UUID) error
+	ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// Erase removes a due account and every row that belongs to it, and
+	// returns the uploaded image URLs nothing else uses any more. The last
+	// admin is kept with ErrLastAdmin.
+	Erase(ctx context.Context, id uuid.UUID) ([]string, error)
 }
 
 // OAuthRepository defines the interface for OAuth-related database operations
@@ -26,31 +43,97 @@ type OAuthRepository interface {
 	GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OAuthAccount, error)
 	UpdateAccount(ctx context.Context, account *models.OAuthAccount) error
 	DeleteAccount(ctx context.Context, id uuid.UUID) error
+	// UnlinkAccount removes one of a user's identities, refusing with
+	// ErrLastOAuthAccount if it's the only one they can sign in with
+	UnlinkAccount(ctx context.Context, id, userID uuid.UUID) error
+	// ReencryptTokens seals every stored provider token with the current
+	// primary key and returns how many accounts were rewritten
+	ReencryptTokens(ctx context.Context) (int, error)
 
 	CreateState(ctx context.Context, state *models.OAuthState) error
 	GetState(ctx context.Context, state string) (*models.OAuthState, error)
 	DeleteState(ctx context.Context, state string) error
 	CleanupExpiredStates(ctx context.Context) error
+
+	CreateExchangeCode(ctx context.Context, code *models.OAuthExchangeCode) error
+	// RedeemExchangeCode uses up a code, returning ErrExchangeCodeNotFound if
+	// it is unknown, expired or already used
+	RedeemExchangeCode(ctx context.Context, code string) (*models.OAuthExchangeCode, error)
 }
 
 // SessionRepository defines the interface for session-related database operations
 type SessionRepository interface {
 	Create(ctx context.Context, session *models.Session) error
 	GetByToken(ctx context.Context, token string) (*models.Session, error)
+	Rotate(ctx context.Context, refreshToken, newAccessToken, newRefreshToken string, accessExpiresAt, expiresAt time.Time) (*models.Session, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error)
 	Update(ctx context.Context, session *models.Session) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByToken(ctx context.Context, token string) error
 	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	// DeleteForUser revokes one session, only if it belongs to userID
+	DeleteForUser(ctx context.Context, id, userID uuid.UUID) error
+	// DeleteByUserIDExcept revokes every session of userID but keepID
+	DeleteByUserIDExcept(ctx context.Context, userID, keepID uuid.UUID) (int64, error)
+	Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error
 	CleanupExpired(ctx context.Context) error
 }
 
+// AccessTokenRepository defines the interface for personal access token
+// database operations
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *models.AccessToken) error
+	GetByToken(ctx context.Context, token string) (*models.AccessToken, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.AccessToken, error)
+	// DeleteForUser revokes one token, only if it belongs to userID
+	DeleteForUser(ctx context.Context, id, userID uuid.UUID) error
+	Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// MagicLinkRepository defines the interface for email sign in link database
+// operations
+type MagicLinkRepository interface {
+	Create(ctx context.Context, link *models.MagicLink) error
+	// Redeem uses up a link, returning ErrMagicLinkNotFound if it is
+	// unknown, expired or already used
+	Redeem(ctx context.Context, token string) (*models.MagicLink, error)
+	CountSince(ctx context.Context, email, ipAddress string, since time.Time) (byEmail, byIP int, err error)
+	CleanupExpired(ctx context.Context, before time.Time) error
+}
+
+// DataExportRepository defines the interface for personal data export
+// operations
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.DataExport, error)
+	// GetActiveByUserID returns the user's pending or running export
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error)
+	// ClaimPending marks the oldest pending export as running and returns
+	// it. Exports stuck running since before staleBefore are picked up
+	// again. Returns ErrDataExportNotFound when there is nothing to do.
+	ClaimPending(ctx context.Context, staleBefore time.Time) (*models.DataExport, error)
+	MarkReady(ctx context.Context, id uuid.UUID, sizeBytes int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+	// ExpireReady marks ready exports past their expiry as expired and
+	// returns them so their archives can be removed
+	ExpireReady(ctx context.Context, now time.Time) ([]*models.DataExport, error)
+}
+
+// MaintenanceRepository defines housekeeping that spans tables
+type MaintenanceRepository interface {
+	// PurgeSoftDeleted hard-deletes rows soft-deleted before the cutoff and
+	// returns the counts per table and the image URLs left unused
+	PurgeSoftDeleted(ctx context.Context, before time.Time) (map[string]int64, []string, error)
+}
+
 // PlaceRepository defines the interface for place-related database operations
 type PlaceRepository interface {
 	Create(ctx context.Context, place *models.Place) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Place, error)
 	Update(ctx context.Context, place *models.Place) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete soft-deletes the place; actorID must be its creator or a moderator
+	Delete(ctx context.Context, id, actorID uuid.UUID) error
 	List(ctx context.Context, limit, offset int) ([]*models.Place, error)
 	Search(ctx context.Context, query string, limit, offset int) ([]*models.Place, error)
 	SearchNearby(ctx context.Context, lat, lng float64, radiusKm float64, limit int) ([]*models.Place, error)
@@ -64,10 +147,15 @@ type PlaceRepository interface {
 type PostRepository interface {
 	Create(ctx context.Context, post *models.Post) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Post, error)
+	// GetVisibleByID is GetByID for showing a post to viewerID; it's
+	// ErrPostNotFound if the post's visibility or its author's privacy or
+	// blocks hide it from viewerID
+	GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Post, error)
 	Update(ctx context.Context, post *models.Post) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Post, error)
-	ListByPlaceID(ctx context.Context, placeID uuid.UUID, limit, offset int) ([]*models.Post, error)
+	// Delete soft-deletes the post; actorID must be its author or a moderator
+	Delete(ctx context.Context, id, actorID uuid.UUID) error
+	ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error)
+	ListByPlaceID(ctx context.Context, placeID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error)
 	ListFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Post, error)
 
 	CreateImage(ctx context.Context, image *models.PostImage) error
@@ -81,11 +169,16 @@ type PostRepository interface {
 type CommentRepository interface {
 	Create(ctx context.Context, comment *models.Comment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error)
+	// GetVisibleByID is GetByID for showing a comment to viewerID; it's
+	// ErrCommentNotFound if either has blocked the other or the post it's on
+	// is hidden from viewerID. The list methods apply the same rules.
+	GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Comment, error)
 	Update(ctx context.Context, comment *models.Comment) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	ListByPostID(ctx context.Context, postID uuid.UUID, limit, offset int) ([]*models.Comment, error)
-	ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Comment, error)
-	GetReplies(ctx context.Context, parentID uuid.UUID, limit, offset int) ([]*models.Comment, error)
+	// Delete soft-deletes the comment; actorID must be its author or a moderator
+	Delete(ctx context.Context, id, actorID uuid.UUID) error
+	ListByPostID(ctx context.Context, postID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error)
+	ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error)
+	GetReplies(ctx context.Context, parentID, viewerID uuid.UUID, limit, offset int) ([]*models.Comment, error)
 	CountByPostID(ctx context.Context, postID uuid.UUID) (int, error)
 }
 
@@ -113,6 +206,20 @@ type NotificationRepository interface {
 	SoftDeleteByReference(ctx context.Context, userID, actorID uuid.UUID, notifType models.NotificationType, postID *uuid.UUID, commentID *uuid.UUID) error
 }
 
+// BlockRepository defines the interface for blocking and muting users. The
+// other repositories read both tables directly so every query honors them.
+type BlockRepository interface {
+	// Block also removes any follows between the two users
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	// IsBlocked reports whether either user has blocked the other
+	IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+	ListBlocked(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error)
+	Mute(ctx context.Context, muterID, mutedID uuid.UUID) error
+	Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error
+	ListMuted(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.User, error)
+}
+
 // LikeRepository defines the interface for post like operations
 type LikeRepository interface {
 	LikePost(ctx context.Context, postID, userID uuid.UUID) error
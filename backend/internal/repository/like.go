@@ -16,17 +16,39 @@ func NewLikeRepository(db *database.DB) LikeRepository {
 	return &likeRepository{db: db}
 }
 
+// LikePost likes the post, or fails with ErrBlocked if its author and userID
+// have a block between them. The block check is part of the insert, so a
+// block made at the same time can't slip a like through.
 func (r *likeRepository) LikePost(ctx context.Context, postID, userID uuid.UUID) error {
 	query := `
 		INSERT INTO post_likes (id, post_id, user_id)
-		VALUES ($1, $2, $3)
+		SELECT $1::uuid, $2::uuid, $3::uuid
+		WHERE NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = $2 AND ` + blockedSQL("p.user_id", "$3") + `)
 		ON CONFLICT (post_id, user_id) DO NOTHING
 	`
 
-	if _, err := r.db.GetConnection().ExecContext(ctx, query, uuid.New(), postID, userID); err != nil {
+	result, err := r.db.GetConnection().ExecContext(ctx, query, uuid.New(), postID, userID)
+	if err != nil {
 		return fmt.Errorf("failed to like post: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// nothing inserted: either it's already liked, which is fine, or
+		// there's a block
+		liked, err := r.IsPostLikedByUser(ctx, postID, userID)
+		if err != nil {
+			return err
+		}
+		if !liked {
+			return ErrBlocked
+		}
+	}
+
 	return nil
 }
 
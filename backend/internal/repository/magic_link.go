@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+)
+
+type magicLinkRepository struct {
+	db *database.DB
+}
+
+func NewMagicLinkRepository(db *database.DB) MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+func (r *magicLinkRepository) Create(ctx context.Context, link *models.MagicLink) error {
+	query := `
+		INSERT INTO magic_links (id, email, token_hash, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.GetConnection().ExecContext(ctx, query,
+		link.ID, link.Email, hashToken(link.Token), link.IPAddress, link.ExpiresAt, link.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	return nil
+}
+
+// Redeem marks an unexpired, unused link as used and returns it. Doing both
+// in one statement means two requests racing with the same token can't both
+// win.
+func (r *magicLinkRepository) Redeem(ctx context.Context, token string) (*models.MagicLink, error) {
+	query := `
+		UPDATE magic_links
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, email, ip_address, expires_at, used_at, created_at
+	`
+
+	link := &models.MagicLink{}
+	err := r.db.GetConnection().QueryRowContext(ctx, query, hashToken(token)).Scan(
+		&link.ID, &link.Email, &link.IPAddress, &link.ExpiresAt, &link.UsedAt, &link.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMagicLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to redeem magic link: %w", err)
+	}
+
+	return link, nil
+}
+
+// CountSince counts links requested for email, and from ipAddress, since the
+// given time
+func (r *magicLinkRepository) CountSince(ctx context.Context, email, ipAddress string, since time.Time) (byEmail, byIP int, err error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE LOWER(email) = LOWER($1)),
+			COUNT(*) FILTER (WHERE ip_address = $2)
+		FROM magic_links
+		WHERE created_at > $3 AND (LOWER(email) = LOWER($1) OR ip_address = $2)
+	`
+
+	err = r.db.GetConnection().QueryRowContext(ctx, query, email, ipAddress, since).Scan(&byEmail, &byIP)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count magic links: %w", err)
+	}
+
+	return byEmail, byIP, nil
+}
+
+// CleanupExpired deletes links that can no longer be redeemed or counted
+func (r *magicLinkRepository) CleanupExpired(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM magic_links WHERE expires_at < $1`
+
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to cleanup magic links: %w", err)
+	}
+
+	return nil
+}
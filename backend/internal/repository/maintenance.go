@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pin-app/pin/internal/database"
+)
+
+// softDeleteTables lists every table with a deleted_at column, children
+// before parents so a purge doesn't cascade through rows it hasn't reached
+// yet. Purging a parent still takes its live children with it, which is
+// what deleting it meant in the first place.
+var softDeleteTables = []string{
+	"notifications",
+	"comments",
+	"post_images",
+	"posts",
+	"place_comparisons",
+	"place_ratings",
+	"place_relations",
+	"places",
+	"access_tokens",
+	"sessions",
+	"oauth_accounts",
+	"users",
+}
+
+type maintenanceRepository struct {
+	db *database.DB
+}
+
+func NewMaintenanceRepository(db *database.DB) MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+// PurgeSoftDeleted hard-deletes rows soft-deleted before the cutoff. It
+// returns how many were removed per table, and the uploaded image URLs of
+// purged posts and users that nothing else uses any more.
+func (r *maintenanceRepository) PurgeSoftDeleted(ctx context.Context, before time.Time) (map[string]int64, []string, error) {
+	purged := make(map[string]int64, len(softDeleteTables))
+	var orphaned []string
+
+	err := r.db.WithTx(func(tx *sql.Tx) error {
+		// images go with their post, and posts with their user or place, so
+		// look for them before anything is deleted
+		rows, err := tx.QueryContext(ctx, `
+			SELECT pi.image_url FROM post_images pi
+			JOIN posts p ON p.id = pi.post_id
+			JOIN users u ON u.id = p.user_id
+			JOIN places pl ON pl.id = p.place_id
+			WHERE pi.deleted_at < $1 OR p.deleted_at < $1 OR u.deleted_at < $1 OR pl.deleted_at < $1
+			UNION
+			SELECT pfp_url FROM users
+			WHERE pfp_url IS NOT NULL AND deleted_at < $1
+		`, before)
+		if err != nil {
+			return fmt.Errorf("failed to list purged images: %w", err)
+		}
+		var urls []string
+		for rows.Next() {
+			var url string
+			if err := rows.Scan(&url); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan purged image: %w", err)
+			}
+			urls = append(urls, url)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate purged images: %w", err)
+		}
+
+		for _, table := range softDeleteTables {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at < $1`, table)
+
+			result, err := tx.ExecContext(ctx, query, before)
+			if err != nil {
+				return fmt.Errorf("failed to purge %s: %w", table, err)
+			}
+
+			n, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			purged[table] = n
+		}
+
+		orphaned, err = unreferencedImages(ctx, tx, urls)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return purged, orphaned, nil
+}
+
+// unreferencedImages filters urls down to the ones no post image or profile
+// picture uses any more. Someone else may have posted the same URL, so
+// those files are kept.
+func unreferencedImages(ctx context.Context, tx *sql.Tx, urls []string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT url FROM unnest($1::text[]) AS url
+		WHERE NOT EXISTS (SELECT 1 FROM post_images WHERE image_url = url)
+		AND NOT EXISTS (SELECT 1 FROM users WHERE pfp_url = url)
+	`, pq.Array(urls))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check image references: %w", err)
+	}
+	defer rows.Close()
+
+	var orphaned []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("failed to scan image URL: %w", err)
+		}
+		orphaned = append(orphaned, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate image URLs: %w", err)
+	}
+	return orphaned, nil
+}
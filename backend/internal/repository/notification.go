@@ -28,12 +28,14 @@ func (r *notificationRepository) Create(ctx context.Context, notification *model
 		return fmt.Errorf("marshal notification data: %w", err)
 	}
 
+	// nobody hears from a user they've blocked, or who blocked them
 	query := `
 		INSERT INTO notifications (id, user_id, actor_id, post_id, comment_id, type, data, read_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4::uuid, $5::uuid, $6::text, $7::jsonb, $8::timestamptz, $9::timestamptz, $10::timestamptz
+		WHERE NOT ` + blockedSQL("$2::uuid", "$3::uuid") + `
 	`
 
-	_, err = r.db.GetConnection().ExecContext(ctx, query,
+	result, err := r.db.GetConnection().ExecContext(ctx, query,
 		notification.ID,
 		notification.UserID,
 		notification.ActorID,
@@ -50,6 +52,15 @@ func (r *notificationRepository) Create(ctx context.Context, notification *model
 		return fmt.Errorf("create notification: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("create notification: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrBlocked
+	}
+
 	return nil
 }
 
@@ -57,7 +68,7 @@ func (r *notificationRepository) ListByUserID(ctx context.Context, userID uuid.U
 	query := `
 		SELECT id, user_id, actor_id, post_id, comment_id, type, data, read_at, created_at, updated_at, deleted_at
 		FROM notifications
-		WHERE user_id = $1 AND deleted_at IS NULL
+		WHERE user_id = $1 AND deleted_at IS NULL AND NOT ` + blockedSQL("user_id", "actor_id") + `
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -8,26 +8,39 @@ import (
 	"github.com/google/uuid"
 	"github.com/pin-app/pin/internal/database"
 	"github.com/pin-app/pin/internal/models"
+	"github.com/pin-app/pin/internal/secrets"
 )
 
+// reencryptBatchSize is how many accounts ReencryptTokens rewrites per query
+const reencryptBatchSize = 100
+
 type oauthRepository struct {
-	db *database.DB
+	db   *database.DB
+	keys *secrets.Keyring
 }
 
-func NewOAuthRepository(db *database.DB) OAuthRepository {
-	return &oauthRepository{db: db}
+// NewOAuthRepository returns an OAuthRepository that encrypts provider tokens
+// with keys. A nil keyring stores them as is, which is only meant for local
+// development.
+func NewOAuthRepository(db *database.DB, keys *secrets.Keyring) OAuthRepository {
+	return &oauthRepository{db: db, keys: keys}
 }
 
 func (r *oauthRepository) CreateAccount(ctx context.Context, account *models.OAuthAccount) error {
+	accessToken, refreshToken, err := r.sealTokens(account)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO oauth_accounts (id, user_id, provider, provider_id, provider_email, provider_name, 
-			access_token, refresh_token, token_expires_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO oauth_accounts (id, user_id, provider, provider_id, provider_email, provider_email_verified,
+			provider_name, access_token, refresh_token, token_expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
-	_, err := r.db.GetConnection().ExecContext(ctx, query,
+	_, err = r.db.GetConnection().ExecContext(ctx, query,
 		account.ID, account.UserID, account.Provider, account.ProviderID, account.ProviderEmail,
-		account.ProviderName, account.AccessToken, account.RefreshToken, account.TokenExpiresAt,
+		account.EmailVerified, account.ProviderName, accessToken, refreshToken, account.TokenExpiresAt,
 		account.CreatedAt, account.UpdatedAt,
 	)
 
@@ -40,7 +53,7 @@ func (r *oauthRepository) CreateAccount(ctx context.Context, account *models.OAu
 
 func (r *oauthRepository) GetAccountByProvider(ctx context.Context, provider models.OAuthProvider, providerID string) (*models.OAuthAccount, error) {
 	query := `
-		SELECT id, user_id, provider, provider_id, provider_email, provider_name,
+		SELECT id, user_id, provider, provider_id, provider_email, provider_email_verified, provider_name,
 			access_token, refresh_token, token_expires_at, created_at, updated_at, deleted_at
 		FROM oauth_accounts
 		WHERE provider = $1 AND provider_id = $2 AND deleted_at IS NULL
@@ -49,23 +62,27 @@ func (r *oauthRepository) GetAccountByProvider(ctx context.Context, provider mod
 	account := &models.OAuthAccount{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, provider, providerID).Scan(
 		&account.ID, &account.UserID, &account.Provider, &account.ProviderID, &account.ProviderEmail,
-		&account.ProviderName, &account.AccessToken, &account.RefreshToken, &account.TokenExpiresAt,
+		&account.EmailVerified, &account.ProviderName, &account.AccessToken, &account.RefreshToken, &account.TokenExpiresAt,
 		&account.CreatedAt, &account.UpdatedAt, &account.DeletedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("OAuth account not found")
+			return nil, ErrOAuthAccountNotFound
 		}
 		return nil, fmt.Errorf("failed to get OAuth account: %w", err)
 	}
 
+	if err := r.openTokens(account); err != nil {
+		return nil, err
+	}
+
 	return account, nil
 }
 
 func (r *oauthRepository) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OAuthAccount, error) {
 	query := `
-		SELECT id, user_id, provider, provider_id, provider_email, provider_name,
+		SELECT id, user_id, provider, provider_id, provider_email, provider_email_verified, provider_name,
 			access_token, refresh_token, token_expires_at, created_at, updated_at, deleted_at
 		FROM oauth_accounts
 		WHERE user_id = $1 AND deleted_at IS NULL
@@ -83,12 +100,15 @@ func (r *oauthRepository) GetAccountsByUserID(ctx context.Context, userID uuid.U
 		account := &models.OAuthAccount{}
 		err := rows.Scan(
 			&account.ID, &account.UserID, &account.Provider, &account.ProviderID, &account.ProviderEmail,
-			&account.ProviderName, &account.AccessToken, &account.RefreshToken, &account.TokenExpiresAt,
+			&account.EmailVerified, &account.ProviderName, &account.AccessToken, &account.RefreshToken, &account.TokenExpiresAt,
 			&account.CreatedAt, &account.UpdatedAt, &account.DeletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan OAuth account: %w", err)
 		}
+		if err := r.openTokens(account); err != nil {
+			return nil, err
+		}
 		accounts = append(accounts, account)
 	}
 
@@ -100,6 +120,11 @@ func (r *oauthRepository) GetAccountsByUserID(ctx context.Context, userID uuid.U
 }
 
 func (r *oauthRepository) UpdateAccount(ctx context.Context, account *models.OAuthAccount) error {
+	accessToken, refreshToken, err := r.sealTokens(account)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE oauth_accounts
 		SET provider_email = $3, provider_name = $4, access_token = $5, refresh_token = $6,
@@ -109,7 +134,7 @@ func (r *oauthRepository) UpdateAccount(ctx context.Context, account *models.OAu
 
 	result, err := r.db.GetConnection().ExecContext(ctx, query,
 		account.ID, account.UserID, account.ProviderEmail, account.ProviderName,
-		account.AccessToken, account.RefreshToken, account.TokenExpiresAt, account.UpdatedAt,
+		accessToken, refreshToken, account.TokenExpiresAt, account.UpdatedAt,
 	)
 
 	if err != nil {
@@ -152,15 +177,60 @@ func (r *oauthRepository) DeleteAccount(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+func (r *oauthRepository) UnlinkAccount(ctx context.Context, id, userID uuid.UUID) error {
+	return r.db.WithTx(func(tx *sql.Tx) error {
+		// lock the user so two unlinks racing each other can't both see
+		// the other identity and leave the account with none
+		var locked uuid.UUID
+		err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&locked)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+
+		var owned, remaining int
+		err = tx.QueryRowContext(ctx, `
+			SELECT
+				COUNT(*) FILTER (WHERE id = $1),
+				COUNT(*) FILTER (WHERE id <> $1)
+			FROM oauth_accounts
+			WHERE user_id = $2 AND deleted_at IS NULL
+		`, id, userID).Scan(&owned, &remaining)
+		if err != nil {
+			return fmt.Errorf("failed to count OAuth accounts: %w", err)
+		}
+
+		if owned == 0 {
+			return ErrOAuthAccountNotFound
+		}
+		if remaining == 0 {
+			return ErrLastOAuthAccount
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE oauth_accounts
+			SET deleted_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		`, id, userID)
+		if err != nil {
+			return fmt.Errorf("failed to unlink OAuth account: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (r *oauthRepository) CreateState(ctx context.Context, state *models.OAuthState) error {
 	query := `
-		INSERT INTO oauth_states (id, state, code_verifier, provider, redirect_url, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO oauth_states (id, state, code_verifier, nonce, provider, redirect_url, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err := r.db.GetConnection().ExecContext(ctx, query,
-		state.ID, state.State, state.CodeVerifier, state.Provider, state.RedirectURL,
-		state.ExpiresAt, state.CreatedAt,
+		state.ID, state.State, state.CodeVerifier, state.Nonce, state.Provider, state.RedirectURL,
+		state.UserID, state.ExpiresAt, state.CreatedAt,
 	)
 
 	if err != nil {
@@ -172,15 +242,15 @@ func (r *oauthRepository) CreateState(ctx context.Context, state *models.OAuthSt
 
 func (r *oauthRepository) GetState(ctx context.Context, state string) (*models.OAuthState, error) {
 	query := `
-		SELECT id, state, code_verifier, provider, redirect_url, expires_at, created_at
+		SELECT id, state, code_verifier, nonce, provider, redirect_url, user_id, expires_at, created_at
 		FROM oauth_states
 		WHERE state = $1 AND expires_at > NOW()
 	`
 
 	oauthState := &models.OAuthState{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, state).Scan(
-		&oauthState.ID, &oauthState.State, &oauthState.CodeVerifier, &oauthState.Provider,
-		&oauthState.RedirectURL, &oauthState.ExpiresAt, &oauthState.CreatedAt,
+		&oauthState.ID, &oauthState.State, &oauthState.CodeVerifier, &oauthState.Nonce, &oauthState.Provider,
+		&oauthState.RedirectURL, &oauthState.UserID, &oauthState.ExpiresAt, &oauthState.CreatedAt,
 	)
 
 	if err != nil {
@@ -221,5 +291,188 @@ func (r *oauthRepository) CleanupExpiredStates(ctx context.Context) error {
 		return fmt.Errorf("failed to cleanup expired OAuth states: %w", err)
 	}
 
+	query = `DELETE FROM oauth_exchange_codes WHERE expires_at <= NOW()`
+
+	_, err = r.db.GetConnection().ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired OAuth exchange codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oauthRepository) CreateExchangeCode(ctx context.Context, code *models.OAuthExchangeCode) error {
+	query := `
+		INSERT INTO oauth_exchange_codes (id, code_hash, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.GetConnection().ExecContext(ctx, query,
+		code.ID, hashToken(code.Code), code.UserID, code.ExpiresAt, code.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth exchange code: %w", err)
+	}
+
 	return nil
 }
+
+// RedeemExchangeCode uses up an unexpired code in a single statement, so the
+// same code can't be exchanged twice even by racing requests
+func (r *oauthRepository) RedeemExchangeCode(ctx context.Context, code string) (*models.OAuthExchangeCode, error) {
+	query := `
+		UPDATE oauth_exchange_codes
+		SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, expires_at, used_at, created_at
+	`
+
+	exchangeCode := &models.OAuthExchangeCode{}
+	err := r.db.GetConnection().QueryRowContext(ctx, query, hashToken(code)).Scan(
+		&exchangeCode.ID, &exchangeCode.UserID, &exchangeCode.ExpiresAt, &exchangeCode.UsedAt, &exchangeCode.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExchangeCodeNotFound
+		}
+		return nil, fmt.Errorf("failed to redeem OAuth exchange code: %w", err)
+	}
+
+	return exchangeCode, nil
+}
+
+// ReencryptTokens rewrites every stored provider token that isn't sealed with
+// the keyring's primary key, including those of unlinked accounts. It works
+// in batches so a large table isn't locked for the whole run, and returns the
+// number of accounts rewritten. Accounts whose tokens changed after they were
+// read are left alone; they were written with the primary key anyway.
+func (r *oauthRepository) ReencryptTokens(ctx context.Context) (int, error) {
+	if r.keys == nil {
+		return 0, fmt.Errorf("no token encryption keys configured")
+	}
+
+	updated := 0
+	after := uuid.Nil
+	for {
+		rows, err := r.db.GetConnection().QueryContext(ctx, `
+			SELECT id, access_token, refresh_token
+			FROM oauth_accounts
+			WHERE id > $1 AND (access_token IS NOT NULL OR refresh_token IS NOT NULL)
+			ORDER BY id
+			LIMIT $2
+		`, after, reencryptBatchSize)
+		if err != nil {
+			return updated, fmt.Errorf("failed to list OAuth accounts: %w", err)
+		}
+
+		var batch []*models.OAuthAccount
+		for rows.Next() {
+			account := &models.OAuthAccount{}
+			if err := rows.Scan(&account.ID, &account.AccessToken, &account.RefreshToken); err != nil {
+				rows.Close()
+				return updated, fmt.Errorf("failed to scan OAuth account: %w", err)
+			}
+			batch = append(batch, account)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, fmt.Errorf("failed to iterate OAuth accounts: %w", err)
+		}
+		if len(batch) == 0 {
+			return updated, nil
+		}
+
+		for _, account := range batch {
+			after = account.ID
+			if !r.needsReseal(account.AccessToken) && !r.needsReseal(account.RefreshToken) {
+				continue
+			}
+
+			readAccess, readRefresh := account.AccessToken, account.RefreshToken
+			if err := r.openTokens(account); err != nil {
+				return updated, fmt.Errorf("account %s: %w", account.ID, err)
+			}
+			accessToken, refreshToken, err := r.sealTokens(account)
+			if err != nil {
+				return updated, err
+			}
+
+			// a login may have stored fresh tokens since the batch was read;
+			// only overwrite the ciphertexts we actually re-sealed
+			result, err := r.db.GetConnection().ExecContext(ctx, `
+				UPDATE oauth_accounts
+				SET access_token = $2, refresh_token = $3
+				WHERE id = $1
+					AND access_token IS NOT DISTINCT FROM $4
+					AND refresh_token IS NOT DISTINCT FROM $5
+			`, account.ID, accessToken, refreshToken, readAccess, readRefresh)
+			if err != nil {
+				return updated, fmt.Errorf("failed to update OAuth account tokens: %w", err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return updated, fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				continue
+			}
+			updated++
+		}
+	}
+}
+
+// sealTokens returns the account's tokens as they should be stored, leaving
+// the account itself untouched
+func (r *oauthRepository) sealTokens(account *models.OAuthAccount) (accessToken, refreshToken *string, err error) {
+	if accessToken, err = r.seal(account.AccessToken); err != nil {
+		return nil, nil, err
+	}
+	if refreshToken, err = r.seal(account.RefreshToken); err != nil {
+		return nil, nil, err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// openTokens decrypts the tokens of an account read from the database in place
+func (r *oauthRepository) openTokens(account *models.OAuthAccount) error {
+	var err error
+	if account.AccessToken, err = r.open(account.AccessToken); err != nil {
+		return fmt.Errorf("failed to decrypt OAuth access token: %w", err)
+	}
+	if account.RefreshToken, err = r.open(account.RefreshToken); err != nil {
+		return fmt.Errorf("failed to decrypt OAuth refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *oauthRepository) seal(value *string) (*string, error) {
+	if value == nil || r.keys == nil {
+		return value, nil
+	}
+	sealed, err := r.keys.Seal(*value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt OAuth token: %w", err)
+	}
+	return &sealed, nil
+}
+
+func (r *oauthRepository) open(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if r.keys == nil {
+		if secrets.IsSealed(*value) {
+			return nil, fmt.Errorf("token is encrypted but no keys are configured")
+		}
+		return value, nil
+	}
+	opened, err := r.keys.Open(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &opened, nil
+}
+
+func (r *oauthRepository) needsReseal(value *string) bool {
+	return value != nil && r.keys.NeedsReseal(*value)
+}
@@ -22,8 +22,8 @@ func NewPlaceRepository(db *database.DB) PlaceRepository {
 
 func (r *placeRepository) Create(ctx context.Context, place *models.Place) error {
 	query := `
-		INSERT INTO places (id, name, geometry, properties, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO places (id, name, geometry, properties, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	// Convert Properties to JSON
@@ -37,7 +37,7 @@ func (r *placeRepository) Create(ctx context.Context, place *models.Place) error
 	}
 
 	_, err = r.db.GetConnection().ExecContext(ctx, query,
-		place.ID, place.Name, place.Geometry, propertiesJSON, place.CreatedAt, place.UpdatedAt,
+		place.ID, place.Name, place.Geometry, propertiesJSON, place.CreatedBy, place.CreatedAt, place.UpdatedAt,
 	)
 
 	if err != nil {
@@ -49,7 +49,7 @@ func (r *placeRepository) Create(ctx context.Context, place *models.Place) error
 
 func (r *placeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Place, error) {
 	query := `
-		SELECT id, name, geometry, properties, created_at, updated_at, deleted_at
+		SELECT id, name, geometry, properties, created_by, created_at, updated_at, deleted_at
 		FROM places
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -57,7 +57,7 @@ func (r *placeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Pl
 	place := &models.Place{}
 	var propertiesJSON []byte
 	err := r.db.GetConnection().QueryRowContext(ctx, query, id).Scan(
-		&place.ID, &place.Name, &place.Geometry, &propertiesJSON,
+		&place.ID, &place.Name, &place.Geometry, &propertiesJSON, &place.CreatedBy,
 		&place.CreatedAt, &place.UpdatedAt, &place.DeletedAt,
 	)
 
@@ -116,14 +116,17 @@ func (r *placeRepository) Update(ctx context.Context, place *models.Place) error
 	return nil
 }
 
-func (r *placeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *placeRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
 	query := `
 		UPDATE places
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
+		AND (created_by = $2 OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND role IN ('moderator', 'admin') AND deleted_at IS NULL
+		))
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query, id)
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, actorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete place: %w", err)
 	}
@@ -134,7 +137,7 @@ func (r *placeRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("place not found or already deleted")
+		return fmt.Errorf("place not found, not owned by actor, or already deleted")
 	}
 
 	return nil
@@ -142,7 +145,7 @@ func (r *placeRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *placeRepository) List(ctx context.Context, limit, offset int) ([]*models.Place, error) {
 	query := `
-		SELECT id, name, geometry, properties, created_at, updated_at, deleted_at
+		SELECT id, name, geometry, properties, created_by, created_at, updated_at, deleted_at
 		FROM places
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -160,7 +163,7 @@ func (r *placeRepository) List(ctx context.Context, limit, offset int) ([]*model
 		place := &models.Place{}
 		var propertiesJSON []byte
 		err := rows.Scan(
-			&place.ID, &place.Name, &place.Geometry, &propertiesJSON,
+			&place.ID, &place.Name, &place.Geometry, &propertiesJSON, &place.CreatedBy,
 			&place.CreatedAt, &place.UpdatedAt, &place.DeletedAt,
 		)
 		if err != nil {
@@ -187,7 +190,7 @@ func (r *placeRepository) List(ctx context.Context, limit, offset int) ([]*model
 
 func (r *placeRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.Place, error) {
 	searchQuery := `
-		SELECT id, name, geometry, properties, created_at, updated_at, deleted_at
+		SELECT id, name, geometry, properties, created_by, created_at, updated_at, deleted_at
 		FROM places
 		WHERE deleted_at IS NULL
 		AND LOWER(name) LIKE LOWER($1)
@@ -207,7 +210,7 @@ func (r *placeRepository) Search(ctx context.Context, query string, limit, offse
 		place := &models.Place{}
 		var propertiesJSON []byte
 		err := rows.Scan(
-			&place.ID, &place.Name, &place.Geometry, &propertiesJSON,
+			&place.ID, &place.Name, &place.Geometry, &propertiesJSON, &place.CreatedBy,
 			&place.CreatedAt, &place.UpdatedAt, &place.DeletedAt,
 		)
 		if err != nil {
@@ -234,7 +237,7 @@ func (r *placeRepository) Search(ctx context.Context, query string, limit, offse
 
 func (r *placeRepository) SearchNearby(ctx context.Context, lat, lng float64, radiusKm float64, limit int) ([]*models.Place, error) {
 	query := `
-		SELECT id, name, geometry, properties, created_at, updated_at, deleted_at,
+		SELECT id, name, geometry, properties, created_by, created_at, updated_at, deleted_at,
 			ST_Distance(geometry, ST_SetSRID(ST_MakePoint($2, $1), 4326)) as distance
 		FROM places
 		WHERE deleted_at IS NULL
@@ -255,7 +258,7 @@ func (r *placeRepository) SearchNearby(ctx context.Context, lat, lng float64, ra
 		place := &models.Place{}
 		var distance float64
 		err := rows.Scan(
-			&place.ID, &place.Name, &place.Geometry, &place.Properties,
+			&place.ID, &place.Name, &place.Geometry, &place.Properties, &place.CreatedBy,
 			&place.CreatedAt, &place.UpdatedAt, &place.DeletedAt, &distance,
 		)
 		if err != nil {
@@ -19,13 +19,17 @@ func NewPostRepository(db *database.DB) PostRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	if post.Visibility == "" {
+		post.Visibility = models.PostVisibilityPublic
+	}
+
 	query := `
-		INSERT INTO posts (id, user_id, place_id, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO posts (id, user_id, place_id, description, visibility, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	_, err := r.db.GetConnection().ExecContext(ctx, query,
-		post.ID, post.UserID, post.PlaceID, post.Description, post.CreatedAt, post.UpdatedAt,
+		post.ID, post.UserID, post.PlaceID, post.Description, post.Visibility, post.CreatedAt, post.UpdatedAt,
 	)
 
 	if err != nil {
@@ -37,14 +41,14 @@ func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
 
 func (r *postRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Post, error) {
 	query := `
-		SELECT id, user_id, place_id, description, created_at, updated_at, deleted_at
+		SELECT id, user_id, place_id, description, visibility, created_at, updated_at, deleted_at
 		FROM posts
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	post := &models.Post{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, id).Scan(
-		&post.ID, &post.UserID, &post.PlaceID, &post.Description,
+		&post.ID, &post.UserID, &post.PlaceID, &post.Description, &post.Visibility,
 		&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
 	)
 
@@ -58,15 +62,38 @@ func (r *postRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Pos
 	return post, nil
 }
 
+func (r *postRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Post, error) {
+	query := `
+		SELECT id, user_id, place_id, description, visibility, created_at, updated_at, deleted_at
+		FROM posts
+		WHERE id = $1 AND deleted_at IS NULL AND ` + canViewPostSQL("posts", "$2") + `
+	`
+
+	post := &models.Post{}
+	err := r.db.GetConnection().QueryRowContext(ctx, query, id, viewerID).Scan(
+		&post.ID, &post.UserID, &post.PlaceID, &post.Description, &post.Visibility,
+		&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to get post by ID: %w", err)
+	}
+
+	return post, nil
+}
+
 func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
 	query := `
 		UPDATE posts
-		SET description = $3, updated_at = $4
+		SET description = $3, visibility = $4, updated_at = $5
 		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 
 	result, err := r.db.GetConnection().ExecContext(ctx, query,
-		post.ID, post.UserID, post.Description, post.UpdatedAt,
+		post.ID, post.UserID, post.Description, post.Visibility, post.UpdatedAt,
 	)
 
 	if err != nil {
@@ -85,14 +112,17 @@ func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
 	return nil
 }
 
-func (r *postRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *postRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
 	query := `
 		UPDATE posts
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
+		AND (user_id = $2 OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND role IN ('moderator', 'admin') AND deleted_at IS NULL
+		))
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query, id)
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, actorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
@@ -103,22 +133,22 @@ func (r *postRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("post not found or already deleted")
+		return fmt.Errorf("post not found, not owned by actor, or already deleted")
 	}
 
 	return nil
 }
 
-func (r *postRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+func (r *postRepository) ListByUserID(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error) {
 	query := `
-		SELECT id, user_id, place_id, description, created_at, updated_at, deleted_at
+		SELECT id, user_id, place_id, description, visibility, created_at, updated_at, deleted_at
 		FROM posts
-		WHERE user_id = $1 AND deleted_at IS NULL
+		WHERE user_id = $1 AND deleted_at IS NULL AND ` + canViewPostSQL("posts", "$2") + `
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list posts by user ID: %w", err)
 	}
@@ -128,7 +158,7 @@ func (r *postRepository) ListByUserID(ctx context.Context, userID uuid.UUID, lim
 	for rows.Next() {
 		post := &models.Post{}
 		err := rows.Scan(
-			&post.ID, &post.UserID, &post.PlaceID, &post.Description,
+			&post.ID, &post.UserID, &post.PlaceID, &post.Description, &post.Visibility,
 			&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
 		)
 		if err != nil {
@@ -144,16 +174,16 @@ func (r *postRepository) ListByUserID(ctx context.Context, userID uuid.UUID, lim
 	return posts, nil
 }
 
-func (r *postRepository) ListByPlaceID(ctx context.Context, placeID uuid.UUID, limit, offset int) ([]*models.Post, error) {
+func (r *postRepository) ListByPlaceID(ctx context.Context, placeID, viewerID uuid.UUID, limit, offset int) ([]*models.Post, error) {
 	query := `
-		SELECT id, user_id, place_id, description, created_at, updated_at, deleted_at
+		SELECT id, user_id, place_id, description, visibility, created_at, updated_at, deleted_at
 		FROM posts
-		WHERE place_id = $1 AND deleted_at IS NULL
+		WHERE place_id = $1 AND deleted_at IS NULL AND ` + canViewPostSQL("posts", "$2") + `
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, placeID, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, placeID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list posts by place ID: %w", err)
 	}
@@ -163,7 +193,7 @@ func (r *postRepository) ListByPlaceID(ctx context.Context, placeID uuid.UUID, l
 	for rows.Next() {
 		post := &models.Post{}
 		err := rows.Scan(
-			&post.ID, &post.UserID, &post.PlaceID, &post.Description,
+			&post.ID, &post.UserID, &post.PlaceID, &post.Description, &post.Visibility,
 			&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
 		)
 		if err != nil {
@@ -182,15 +212,18 @@ func (r *postRepository) ListByPlaceID(ctx context.Context, placeID uuid.UUID, l
 func (r *postRepository) ListFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Post, error) {
 	// For now, this is a simple implementation that returns all posts
 	// In a real app, this would include posts from followed users, nearby places, etc.
+	// Private accounts and followers-only posts only show up for approved
+	// followers, and muted users don't show up at all.
 	query := `
-		SELECT p.id, p.user_id, p.place_id, p.description, p.created_at, p.updated_at, p.deleted_at
+		SELECT p.id, p.user_id, p.place_id, p.description, p.visibility, p.created_at, p.updated_at, p.deleted_at
 		FROM posts p
-		WHERE p.deleted_at IS NULL
+		WHERE p.deleted_at IS NULL AND ` + canViewPostSQL("p", "$1") + `
+		AND NOT ` + mutedSQL("$1", "p.user_id") + `
 		ORDER BY p.created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list feed posts: %w", err)
 	}
@@ -200,7 +233,7 @@ func (r *postRepository) ListFeed(ctx context.Context, userID uuid.UUID, limit,
 	for rows.Next() {
 		post := &models.Post{}
 		err := rows.Scan(
-			&post.ID, &post.UserID, &post.PlaceID, &post.Description,
+			&post.ID, &post.UserID, &post.PlaceID, &post.Description, &post.Visibility,
 			&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
 		)
 		if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pin-app/pin/internal/database"
@@ -18,34 +19,135 @@ func NewSessionRepository(db *database.DB) SessionRepository {
 	return &sessionRepository{db: db}
 }
 
+// Create stores a new session along with its first refresh token
 func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
-	query := `
-		INSERT INTO sessions (id, user_id, session_token, expires_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+	return r.db.WithTx(func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO sessions (id, user_id, token_hash, user_agent, ip_address, app_version,
+				last_seen_at, access_expires_at, expires_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`
+
+		_, err := tx.ExecContext(ctx, query,
+			session.ID, session.UserID, hashToken(session.SessionToken), session.UserAgent, session.IPAddress,
+			session.AppVersion, session.LastSeenAt, session.AccessExpiresAt, session.ExpiresAt,
+			session.CreatedAt, session.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
 
-	_, err := r.db.GetConnection().ExecContext(ctx, query,
-		session.ID, session.UserID, session.SessionToken, session.ExpiresAt,
-		session.CreatedAt, session.UpdatedAt,
-	)
+		if err := insertRefreshToken(ctx, tx, session.ID, session.RefreshToken); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Rotate trades a refresh token for a new access/refresh pair, pushing the
+// session's expiry out to expiresAt. Presenting a refresh token that was
+// already rotated means it was copied somewhere, so the whole session is
+// revoked and ErrRefreshTokenReused returned.
+func (r *sessionRepository) Rotate(ctx context.Context, refreshToken, newAccessToken, newRefreshToken string, accessExpiresAt, expiresAt time.Time) (*models.Session, error) {
+	var session *models.Session
+	reused := false
+
+	err := r.db.WithTx(func(tx *sql.Tx) error {
+		var sessionID uuid.UUID
+		var usedAt *time.Time
+		err := tx.QueryRowContext(ctx, `
+			SELECT session_id, used_at
+			FROM session_refresh_tokens
+			WHERE token_hash = $1
+			FOR UPDATE
+		`, hashToken(refreshToken)).Scan(&sessionID, &usedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSessionNotFound
+			}
+			return fmt.Errorf("failed to get refresh token: %w", err)
+		}
+
+		if usedAt != nil {
+			// committed rather than rolled back: the revocation is the point
+			reused = true
+			_, err := tx.ExecContext(ctx, `
+				UPDATE sessions
+				SET deleted_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL
+			`, sessionID)
+			if err != nil {
+				return fmt.Errorf("failed to revoke session: %w", err)
+			}
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE session_refresh_tokens SET used_at = NOW() WHERE token_hash = $1`, hashToken(refreshToken))
+		if err != nil {
+			return fmt.Errorf("failed to mark refresh token used: %w", err)
+		}
+
+		session = &models.Session{}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE sessions
+			SET token_hash = $2, access_expires_at = $3, expires_at = $4, last_seen_at = NOW()
+			WHERE id = $1 AND expires_at > NOW() AND deleted_at IS NULL
+			RETURNING id, user_id, user_agent, ip_address, app_version, last_seen_at,
+				access_expires_at, expires_at, created_at, updated_at, deleted_at
+		`, sessionID, hashToken(newAccessToken), accessExpiresAt, expiresAt).Scan(
+			&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.AppVersion, &session.LastSeenAt, &session.AccessExpiresAt, &session.ExpiresAt,
+			&session.CreatedAt, &session.UpdatedAt, &session.DeletedAt,
+		)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSessionNotFound
+			}
+			return fmt.Errorf("failed to rotate session: %w", err)
+		}
+
+		if err := insertRefreshToken(ctx, tx, sessionID, newRefreshToken); err != nil {
+			return err
+		}
+		session.SessionToken = newAccessToken
+		session.RefreshToken = newRefreshToken
 
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
 	}
 
+	return session, nil
+}
+
+func insertRefreshToken(ctx context.Context, tx *sql.Tx, sessionID uuid.UUID, token string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO session_refresh_tokens (id, session_id, token_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, uuid.New(), sessionID, hashToken(token))
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
 	return nil
 }
 
 func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*models.Session, error) {
 	query := `
-		SELECT id, user_id, session_token, expires_at, created_at, updated_at, deleted_at
+		SELECT id, user_id, user_agent, ip_address, app_version, last_seen_at,
+			access_expires_at, expires_at, created_at, updated_at, deleted_at
 		FROM sessions
-		WHERE session_token = $1 AND expires_at > NOW() AND deleted_at IS NULL
+		WHERE token_hash = $1 AND access_expires_at > NOW() AND expires_at > NOW() AND deleted_at IS NULL
 	`
 
 	session := &models.Session{}
-	err := r.db.GetConnection().QueryRowContext(ctx, query, token).Scan(
-		&session.ID, &session.UserID, &session.SessionToken, &session.ExpiresAt,
+	err := r.db.GetConnection().QueryRowContext(ctx, query, hashToken(token)).Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.AppVersion, &session.LastSeenAt, &session.AccessExpiresAt, &session.ExpiresAt,
 		&session.CreatedAt, &session.UpdatedAt, &session.DeletedAt,
 	)
 
@@ -61,7 +163,8 @@ func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*mode
 
 func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
 	query := `
-		SELECT id, user_id, session_token, expires_at, created_at, updated_at, deleted_at
+		SELECT id, user_id, user_agent, ip_address, app_version, last_seen_at,
+			access_expires_at, expires_at, created_at, updated_at, deleted_at
 		FROM sessions
 		WHERE user_id = $1 AND expires_at > NOW() AND deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -77,7 +180,8 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (
 	for rows.Next() {
 		session := &models.Session{}
 		err := rows.Scan(
-			&session.ID, &session.UserID, &session.SessionToken, &session.ExpiresAt,
+			&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.AppVersion, &session.LastSeenAt, &session.AccessExpiresAt, &session.ExpiresAt,
 			&session.CreatedAt, &session.UpdatedAt, &session.DeletedAt,
 		)
 		if err != nil {
@@ -148,10 +252,10 @@ func (r *sessionRepository) DeleteByToken(ctx context.Context, token string) err
 	query := `
 		UPDATE sessions
 		SET deleted_at = NOW()
-		WHERE session_token = $1 AND deleted_at IS NULL
+		WHERE token_hash = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query, token)
+	result, err := r.db.GetConnection().ExecContext(ctx, query, hashToken(token))
 	if err != nil {
 		return fmt.Errorf("failed to delete session by token: %w", err)
 	}
@@ -183,6 +287,60 @@ func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID
 	return nil
 }
 
+func (r *sessionRepository) DeleteForUser(ctx context.Context, id, userID uuid.UUID) error {
+	query := `
+		UPDATE sessions
+		SET deleted_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) DeleteByUserIDExcept(ctx context.Context, userID, keepID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE sessions
+		SET deleted_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.GetConnection().ExecContext(ctx, query, userID, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions by user ID: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	if _, err := r.db.GetConnection().ExecContext(ctx, query, id, seenAt); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
 func (r *sessionRepository) CleanupExpired(ctx context.Context) error {
 	query := `
 		UPDATE sessions
@@ -195,5 +353,16 @@ func (r *sessionRepository) CleanupExpired(ctx context.Context) error {
 		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
 	}
 
+	// once a session is gone its old refresh tokens can't be reused anyway
+	query = `
+		DELETE FROM session_refresh_tokens
+		WHERE session_id IN (SELECT id FROM sessions WHERE deleted_at IS NOT NULL)
+	`
+
+	_, err = r.db.GetConnection().ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup refresh tokens: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/internal/database"
+	"github.com/pin-app/pin/internal/models"
+)
+
+// sessionStore stands in for Postgres: it keeps the session rows the
+// repository inserts, keyed by whatever it wrote to token_hash
+type sessionStore struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value
+}
+
+func (s *sessionStore) Open(name string) (driver.Conn, error) { return &sessionConn{s}, nil }
+
+type sessionConn struct{ store *sessionStore }
+
+func (c *sessionConn) Prepare(query string) (driver.Stmt, error) {
+	return &sessionStmt{store: c.store, query: query}, nil
+}
+func (c *sessionConn) Close() error              { return nil }
+func (c *sessionConn) Begin() (driver.Tx, error) { return sessionTx{}, nil }
+
+type sessionTx struct{}
+
+func (sessionTx) Commit() error   { return nil }
+func (sessionTx) Rollback() error { return nil }
+
+type sessionStmt struct {
+	store *sessionStore
+	query string
+}
+
+func (s *sessionStmt) Close() error  { return nil }
+func (s *sessionStmt) NumInput() int { return -1 }
+
+func (s *sessionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "INSERT INTO sessions") {
+		s.store.mu.Lock()
+		s.store.rows[args[2].(string)] = args
+		s.store.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *sessionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows := &sessionRows{}
+	if strings.Contains(s.query, "FROM sessions") && strings.Contains(s.query, "token_hash = $1") {
+		s.store.mu.Lock()
+		if row, ok := s.store.rows[args[0].(string)]; ok {
+			// id, user_id, user_agent, ip_address, app_version, last_seen_at,
+			// access_expires_at, expires_at, created_at, updated_at, deleted_at
+			rows.values = [][]driver.Value{{row[0], row[1], row[3], row[4], row[5], row[6], row[7], row[8], row[9], row[10], nil}}
+		}
+		s.store.mu.Unlock()
+	}
+	return rows, nil
+}
+
+type sessionRows struct {
+	values [][]driver.Value
+}
+
+func (r *sessionRows) Columns() []string {
+	return make([]string, 11)
+}
+func (r *sessionRows) Close() error { return nil }
+func (r *sessionRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newSessionTestRepository(t *testing.T) (SessionRepository, *sessionStore) {
+	t.Helper()
+	store := &sessionStore{rows: make(map[string][]driver.Value)}
+	name := "sessionstore-" + uuid.NewString()
+	sql.Register(name, store)
+
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := database.NewWithConnection(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSessionRepository(db), store
+}
+
+func TestSessionRepository_StoresTokenDigest(t *testing.T) {
+	ctx := context.Background()
+	repo, store := newSessionTestRepository(t)
+
+	now := time.Now()
+	session := &models.Session{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		SessionToken:    "raw-session-token",
+		RefreshToken:    "raw-refresh-token",
+		LastSeenAt:      &now,
+		AccessExpiresAt: now.Add(time.Hour),
+		ExpiresAt:       now.Add(24 * time.Hour),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := repo.Create(ctx, session); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("raw-session-token"))
+	digest := hex.EncodeToString(sum[:])
+	if _, ok := store.rows["raw-session-token"]; ok {
+		t.Fatal("Create() stored the raw token in token_hash")
+	}
+	if _, ok := store.rows[digest]; !ok {
+		t.Fatalf("Create() didn't store the token's SHA-256 digest, stored %v", store.rows)
+	}
+
+	// looking up by the raw token finds it; the digest itself is no token
+	got, err := repo.GetByToken(ctx, "raw-session-token")
+	if err != nil {
+		t.Fatalf("GetByToken() error = %v", err)
+	}
+	if got.ID != session.ID || got.UserID != session.UserID {
+		t.Errorf("GetByToken() = %+v, want session %s", got, session.ID)
+	}
+	if _, err := repo.GetByToken(ctx, digest); err == nil {
+		t.Error("GetByToken() accepted the stored digest as a token")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pin-app/pin/internal/database"
@@ -21,13 +22,17 @@ func NewUserRepository(db *database.DB) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, username, bio, location, display_name, pfp_url, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO users (id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
 	_, err := r.db.GetConnection().ExecContext(ctx, query,
 		user.ID, user.Email, user.Username, user.Bio, user.Location,
-		user.DisplayName, user.PfpURL, user.CreatedAt, user.UpdatedAt,
+		user.DisplayName, user.PfpURL, user.Role, user.IsPrivate, user.CreatedAt, user.UpdatedAt,
 	)
 
 	if err != nil {
@@ -39,7 +44,7 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, username, bio, location, display_name, pfp_url, created_at, updated_at, deleted_at
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -47,7 +52,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	user := &models.User{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
-		&user.DisplayName, &user.PfpURL, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
 	)
 
 	if err != nil {
@@ -60,9 +65,34 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return user, nil
 }
 
+// GetVisibleByID is GetByID for showing a profile to viewerID, which fails
+// with ErrUserNotFound if either of them has blocked the other
+func (r *userRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL AND NOT ` + blockedSQL("id", "$2") + `
+	`
+
+	user := &models.User{}
+	err := r.db.GetConnection().QueryRowContext(ctx, query, id, viewerID).Scan(
+		&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
+		&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, username, bio, location, display_name, pfp_url, created_at, updated_at, deleted_at
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
@@ -70,12 +100,12 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	user := &models.User{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
-		&user.DisplayName, &user.PfpURL, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -85,7 +115,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
-		SELECT id, email, username, bio, location, display_name, pfp_url, created_at, updated_at, deleted_at
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
 		FROM users
 		WHERE LOWER(username) = LOWER($1) AND deleted_at IS NULL
 	`
@@ -93,7 +123,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	user := &models.User{}
 	err := r.db.GetConnection().QueryRowContext(ctx, query, username).Scan(
 		&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
-		&user.DisplayName, &user.PfpURL, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
 	)
 
 	if err != nil {
@@ -109,39 +139,117 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET email = $2, username = $3, bio = $4, location = $5, display_name = $6, pfp_url = $7, updated_at = $8
+		SET email = $2, username = $3, bio = $4, location = $5, display_name = $6, pfp_url = $7, is_private = $8, updated_at = $9
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query,
-		user.ID, user.Email, user.Username, user.Bio, user.Location,
-		user.DisplayName, user.PfpURL, user.UpdatedAt,
-	)
+	return r.db.WithTx(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query,
+			user.ID, user.Email, user.Username, user.Bio, user.Location,
+			user.DisplayName, user.PfpURL, user.IsPrivate, user.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
 
-	rowsAffected, err := result.RowsAffected()
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found or already deleted")
+		}
+
+		// a public account has nothing left to approve
+		if !user.IsPrivate {
+			query := `UPDATE follows SET status = 'accepted' WHERE following_id = $1 AND status = 'pending'`
+			if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+				return fmt.Errorf("failed to accept follow requests: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.UserRole) error {
+	return r.db.WithTx(func(tx *sql.Tx) error {
+		others, err := lockOtherAdmins(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var current models.UserRole
+		err = tx.QueryRowContext(ctx, `
+			SELECT role FROM users
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		`, id).Scan(&current)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user role: %w", err)
+		}
+
+		if current == models.RoleAdmin && role != models.RoleAdmin && others == 0 {
+			return ErrLastAdmin
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET role = $2, updated_at = NOW()
+			WHERE id = $1
+		`, id, role); err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+		return nil
+	})
+}
+
+// lockOtherAdmins locks every admin, so two demotions or deletions at once
+// can't each leave the other as the last one, and counts the admins besides
+// id that are staying: not deleted and not scheduled for deletion
+func lockOtherAdmins(ctx context.Context, tx *sql.Tx, id uuid.UUID) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, deletion_scheduled_at FROM users
+		WHERE role = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, models.RoleAdmin)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return 0, fmt.Errorf("failed to lock admins: %w", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("user not found or already deleted")
+	others := 0
+	for rows.Next() {
+		var adminID uuid.UUID
+		var scheduledAt sql.NullTime
+		if err := rows.Scan(&adminID, &scheduledAt); err != nil {
+			return 0, fmt.Errorf("failed to scan admin: %w", err)
+		}
+		if adminID != id && !scheduledAt.Valid {
+			others++
+		}
 	}
-
-	return nil
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to lock admins: %w", err)
+	}
+	return others, nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *userRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
 	query := `
 		UPDATE users
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
+		AND (id = $2 OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND role = 'admin' AND deleted_at IS NULL
+		))
 	`
 
-	result, err := r.db.GetConnection().ExecContext(ctx, query, id)
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, actorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -158,16 +266,16 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (r *userRepository) List(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	query := `
-		SELECT id, email, username, bio, location, display_name, pfp_url, created_at, updated_at, deleted_at
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
 		FROM users
-		WHERE deleted_at IS NULL
+		WHERE deleted_at IS NULL AND NOT ` + blockedSQL("id", "$1") + `
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.GetConnection().QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -178,7 +286,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 		user := &models.User{}
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
-			&user.DisplayName, &user.PfpURL, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+			&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -193,22 +301,22 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 	return users, nil
 }
 
-func (r *userRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error) {
+func (r *userRepository) Search(ctx context.Context, query string, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	searchQuery := `
-		SELECT id, email, username, bio, location, display_name, pfp_url, created_at, updated_at, deleted_at
+		SELECT id, email, username, bio, location, display_name, pfp_url, role, is_private, created_at, updated_at, deleted_at, deletion_scheduled_at
 		FROM users
-		WHERE deleted_at IS NULL
+		WHERE deleted_at IS NULL AND NOT ` + blockedSQL("id", "$2") + `
 		AND (
 			LOWER(username) LIKE LOWER($1) OR
 			LOWER(display_name) LIKE LOWER($1) OR
 			LOWER(bio) LIKE LOWER($1)
 		)
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
 	searchTerm := "%" + strings.ToLower(query) + "%"
-	rows, err := r.db.GetConnection().QueryContext(ctx, searchQuery, searchTerm, limit, offset)
+	rows, err := r.db.GetConnection().QueryContext(ctx, searchQuery, searchTerm, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search users: %w", err)
 	}
@@ -219,7 +327,7 @@ func (r *userRepository) Search(ctx context.Context, query string, limit, offset
 		user := &models.User{}
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Username, &user.Bio, &user.Location,
-			&user.DisplayName, &user.PfpURL, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+			&user.DisplayName, &user.PfpURL, &user.Role, &user.IsPrivate, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DeletionScheduledAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -233,3 +341,187 @@ func (r *userRepository) Search(ctx context.Context, query string, limit, offset
 
 	return users, nil
 }
+
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id, actorID uuid.UUID, at time.Time) (time.Time, error) {
+	var scheduledAt time.Time
+
+	err := r.db.WithTx(func(tx *sql.Tx) error {
+		others, err := lockOtherAdmins(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var role models.UserRole
+		err = tx.QueryRowContext(ctx, `
+			SELECT role FROM users
+			WHERE id = $1 AND deleted_at IS NULL
+			AND (id = $2 OR EXISTS (
+				SELECT 1 FROM users WHERE id = $2 AND role = 'admin' AND deleted_at IS NULL
+			))
+			FOR UPDATE
+		`, id, actorID).Scan(&role)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user role: %w", err)
+		}
+
+		if role == models.RoleAdmin && others == 0 {
+			return ErrLastAdmin
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			UPDATE users
+			SET deletion_scheduled_at = COALESCE(deletion_scheduled_at, $2), updated_at = NOW()
+			WHERE id = $1
+			RETURNING deletion_scheduled_at
+		`, id, at).Scan(&scheduledAt)
+		if err != nil {
+			return fmt.Errorf("failed to schedule user deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return scheduledAt, nil
+}
+
+func (r *userRepository) CancelDeletion(ctx context.Context, id, actorID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET deletion_scheduled_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND deletion_scheduled_at IS NOT NULL
+		AND (id = $2 OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND role = 'admin' AND deleted_at IS NULL
+		))
+	`
+
+	result, err := r.db.GetConnection().ExecContext(ctx, query, id, actorID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel user deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoDeletionPending
+	}
+
+	return nil
+}
+
+func (r *userRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1
+		ORDER BY deletion_scheduled_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.GetConnection().QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users due for deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Erase deletes the users row and lets foreign keys do the rest: sessions
+// and their refresh tokens, OAuth accounts and their tokens, access tokens,
+// posts with their images, comments and likes, the user's own comments,
+// likes, ratings, comparisons, follows both ways and notifications sent or
+// received. Places they added stay, with created_by set to NULL. Magic
+// links only know the email address, so they're deleted by it.
+func (r *userRepository) Erase(ctx context.Context, id uuid.UUID) ([]string, error) {
+	var orphaned []string
+
+	err := r.db.WithTx(func(tx *sql.Tx) error {
+		others, err := lockOtherAdmins(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var email string
+		var role models.UserRole
+		var pfpURL sql.NullString
+		err = tx.QueryRowContext(ctx, `
+			SELECT email, role, pfp_url FROM users
+			WHERE id = $1 AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= NOW()
+			FOR UPDATE
+		`, id).Scan(&email, &role, &pfpURL)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				// cancelled since it was listed
+				return ErrNoDeletionPending
+			}
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+
+		// scheduled before the last admin check existed, or the other
+		// admins have gone since
+		if role == models.RoleAdmin && others == 0 {
+			return ErrLastAdmin
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT pi.image_url FROM post_images pi
+			JOIN posts p ON p.id = pi.post_id
+			WHERE p.user_id = $1
+		`, id)
+		if err != nil {
+			return fmt.Errorf("failed to list post images: %w", err)
+		}
+		var urls []string
+		for rows.Next() {
+			var url string
+			if err := rows.Scan(&url); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan post image: %w", err)
+			}
+			urls = append(urls, url)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate post images: %w", err)
+		}
+		if pfpURL.Valid {
+			urls = append(urls, pfpURL.String)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM magic_links WHERE LOWER(email) = LOWER($1)`, email); err != nil {
+			return fmt.Errorf("failed to delete magic links: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+			return fmt.Errorf("failed to erase user: %w", err)
+		}
+
+		orphaned, err = unreferencedImages(ctx, tx, urls)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orphaned, nil
+}
@@ -33,6 +33,10 @@ func (r *InMemoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return user, nil
 }
 
+func (r *InMemoryUserRepository) GetVisibleByID(ctx context.Context, id, viewerID uuid.UUID) (*models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
 func (r *InMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	for _, user := range r.users {
 		if user.Email == email {
@@ -59,15 +63,73 @@ func (r *InMemoryUserRepository) Update(ctx context.Context, user *models.User)
 	return nil
 }
 
-func (r *InMemoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, exists := r.users[id.String()]; !exists {
+func (r *InMemoryUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.UserRole) error {
+	user, exists := r.users[id.String()]
+	if !exists {
+		return ErrUserNotFound
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		admins := 0
+		for _, u := range r.users {
+			if u.Role == models.RoleAdmin {
+				admins++
+			}
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+	user.Role = role
+	return nil
+}
+
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id, actorID uuid.UUID) error {
+	if _, exists := r.users[id.String()]; !exists || id != actorID {
 		return ErrUserNotFound
 	}
 	delete(r.users, id.String())
 	return nil
 }
 
-func (r *InMemoryUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (r *InMemoryUserRepository) ScheduleDeletion(ctx context.Context, id, actorID uuid.UUID, at time.Time) (time.Time, error) {
+	user, exists := r.users[id.String()]
+	if !exists || id != actorID {
+		return time.Time{}, ErrUserNotFound
+	}
+	if user.DeletionScheduledAt == nil {
+		user.DeletionScheduledAt = &at
+	}
+	return *user.DeletionScheduledAt, nil
+}
+
+func (r *InMemoryUserRepository) CancelDeletion(ctx context.Context, id, actorID uuid.UUID) error {
+	user, exists := r.users[id.String()]
+	if !exists || id != actorID || user.DeletionScheduledAt == nil {
+		return ErrNoDeletionPending
+	}
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+func (r *InMemoryUserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, user := range r.users {
+		if user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(now) && len(ids) < limit {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *InMemoryUserRepository) Erase(ctx context.Context, id uuid.UUID) ([]string, error) {
+	if _, exists := r.users[id.String()]; !exists {
+		return nil, ErrNoDeletionPending
+	}
+	delete(r.users, id.String())
+	return nil, nil
+}
+
+func (r *InMemoryUserRepository) List(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	var users []*models.User
 	count := 0
 	for _, user := range r.users {
@@ -79,7 +141,7 @@ func (r *InMemoryUserRepository) List(ctx context.Context, limit, offset int) ([
 	return users, nil
 }
 
-func (r *InMemoryUserRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error) {
+func (r *InMemoryUserRepository) Search(ctx context.Context, query string, viewerID uuid.UUID, limit, offset int) ([]*models.User, error) {
 	var users []*models.User
 	count := 0
 	for _, user := range r.users {
@@ -299,7 +361,7 @@ func TestUserRepository_Delete(t *testing.T) {
 
 	// Test deleting non-existent user
 	id := uuid.New()
-	err := repo.Delete(context.Background(), id)
+	err := repo.Delete(context.Background(), id, id)
 	if err == nil {
 		t.Errorf("Delete() expected error for non-existent user")
 	}
@@ -317,8 +379,13 @@ func TestUserRepository_Delete(t *testing.T) {
 		t.Fatalf("Create() error = %v", err)
 	}
 
+	// Deleting on behalf of someone else must fail
+	if err := repo.Delete(context.Background(), id, uuid.New()); err == nil {
+		t.Errorf("Delete() expected error when actor is not the user")
+	}
+
 	// Now delete the user
-	err = repo.Delete(context.Background(), id)
+	err = repo.Delete(context.Background(), id, id)
 	if err != nil {
 		t.Errorf("Delete() error = %v", err)
 	}
@@ -361,7 +428,7 @@ func TestUserRepository_List(t *testing.T) {
 	}
 
 	// Test listing users
-	listUsers, err := repo.List(context.Background(), 10, 0)
+	listUsers, err := repo.List(context.Background(), uuid.Nil, 10, 0)
 	if err != nil {
 		t.Errorf("List() error = %v", err)
 	}
@@ -398,7 +465,7 @@ func TestUserRepository_Search(t *testing.T) {
 	}
 
 	// Test searching users
-	searchUsers, err := repo.Search(context.Background(), "test", 10, 0)
+	searchUsers, err := repo.Search(context.Background(), "test", uuid.Nil, 10, 0)
 	if err != nil {
 		t.Errorf("Search() error = %v", err)
 	}
@@ -0,0 +1,40 @@
+package repository
+
+import "fmt"
+
+// canViewOwnerSQL returns a condition that holds when viewer may see content
+// owned by owner: their own, or anyone's they haven't blocked or been blocked
+// by, as long as the account is public or viewer has an accepted follow for
+// it. Both arguments are SQL expressions; an anonymous viewer is passed as
+// uuid.Nil and only sees public accounts.
+func canViewOwnerSQL(owner, viewer string) string {
+	return fmt.Sprintf(`(%[1]s = %[2]s
+		OR (NOT %[3]s
+			AND (NOT EXISTS (SELECT 1 FROM users vu WHERE vu.id = %[1]s AND vu.is_private)
+				OR EXISTS (SELECT 1 FROM follows vf WHERE vf.follower_id = %[2]s AND vf.following_id = %[1]s AND vf.status = 'accepted'))))`,
+		owner, viewer, blockedSQL(owner, viewer))
+}
+
+// canViewPostSQL returns a condition that holds when viewer may see the post
+// row named post: the author must be visible to them and the post's own
+// visibility must allow it
+func canViewPostSQL(post, viewer string) string {
+	return fmt.Sprintf(`(%[3]s
+		AND (%[1]s.user_id = %[2]s
+			OR %[1]s.visibility = 'public'
+			OR (%[1]s.visibility = 'followers'
+				AND EXISTS (SELECT 1 FROM follows pf WHERE pf.follower_id = %[2]s AND pf.following_id = %[1]s.user_id AND pf.status = 'accepted'))))`,
+		post, viewer, canViewOwnerSQL(post+".user_id", viewer))
+}
+
+// blockedSQL returns a condition that holds when either user has blocked the
+// other
+func blockedSQL(a, b string) string {
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM user_blocks ub
+		WHERE (ub.blocker_id = %[1]s AND ub.blocked_id = %[2]s) OR (ub.blocker_id = %[2]s AND ub.blocked_id = %[1]s))`, a, b)
+}
+
+// mutedSQL returns a condition that holds when muter has muted muted
+func mutedSQL(muter, muted string) string {
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM user_mutes um WHERE um.muter_id = %s AND um.muted_id = %s)`, muter, muted)
+}
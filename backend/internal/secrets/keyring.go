@@ -0,0 +1,227 @@
+// Package secrets encrypts values that have to be stored but must not be
+// readable from a database dump, such as third-party OAuth tokens.
+//
+// Values are envelope encrypted: each one gets a fresh data key, the value is
+// sealed with that key using AES-GCM, and the data key is in turn sealed with
+// a key from the Keyring. The sealed value records the ID of the key that
+// wrapped it, so keys can be rotated without losing access to older rows.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of every key in a Keyring, in bytes (AES-256).
+const KeySize = 32
+
+// prefix marks a value as sealed. Anything without it was written before
+// encryption was switched on and is passed through as is.
+const prefix = "enc:v1:"
+
+var (
+	ErrUnknownKey = errors.New("secrets: value sealed with an unknown key")
+	ErrMalformed  = errors.New("secrets: malformed sealed value")
+)
+
+// Keyring holds the keys that may have sealed a value, and which of them new
+// values are sealed with.
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewKeyring builds a Keyring that seals with the key named primary. Keys no
+// longer used for sealing should stay in keys until every row has been
+// re-encrypted.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("secrets: no keys given")
+	}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("secrets: invalid key ID %q", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("secrets: key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+	}
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("secrets: primary key %q is not in the keyring", primary)
+	}
+
+	return &Keyring{primary: primary, keys: keys}, nil
+}
+
+// ParseKeyring reads raw, a comma separated list of id:base64key pairs, and
+// seals with the key named primary (the first listed if empty). It returns
+// nil with no error when raw is empty.
+func ParseKeyring(raw, primary string) (*Keyring, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	keys := make(map[string][]byte)
+	var first string
+	for _, entry := range strings.Split(raw, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("secrets: keyring entry %q is not id:key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("secrets: key %q is not valid base64: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("secrets: key %q listed twice", id)
+		}
+		keys[id] = key
+		if first == "" {
+			first = id
+		}
+	}
+
+	if primary == "" {
+		primary = first
+	}
+
+	return NewKeyring(primary, keys)
+}
+
+// PrimaryKeyID returns the ID of the key new values are sealed with.
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// WithPrimary returns a copy of the keyring that seals with a different one
+// of its keys.
+func (k *Keyring) WithPrimary(id string) (*Keyring, error) {
+	return NewKeyring(id, k.keys)
+}
+
+// Seal encrypts plaintext under a new data key wrapped with the primary key.
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("secrets: failed to generate data key: %w", err)
+	}
+
+	// the key ID is bound to the wrapped key so it can't be swapped for another
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawStdEncoding
+	return prefix + k.primary + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal. Values that were never sealed are
+// returned unchanged.
+func (k *Keyring) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	id, wrapped, sealed, err := split(value)
+	if err != nil {
+		return "", err
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	dataKey, err := open(key, wrapped, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReseal reports whether value is not yet sealed with the primary key.
+func (k *Keyring) NeedsReseal(value string) bool {
+	if !IsSealed(value) {
+		return true
+	}
+	id, _, _, err := split(value)
+	return err != nil || id != k.primary
+}
+
+// IsSealed reports whether value was produced by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func split(value string) (id string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	enc := base64.RawStdEncoding
+	if wrapped, err = enc.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	if sealed, err = enc.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	return parts[0], wrapped, sealed, nil
+}
+
+// seal returns nonce || ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secrets: failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, data, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
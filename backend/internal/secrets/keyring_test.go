@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, primary string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for i, id := range ids {
+		keys[id] = bytes.Repeat([]byte{byte(i + 1)}, KeySize)
+	}
+	k, err := NewKeyring(primary, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+
+	sealed, err := k.Seal("ya29.secret")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if strings.Contains(sealed, "ya29.secret") || !IsSealed(sealed) {
+		t.Fatalf("value not sealed: %q", sealed)
+	}
+
+	got, err := k.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got != "ya29.secret" {
+		t.Errorf("Open = %q, want %q", got, "ya29.secret")
+	}
+
+	// rows written before encryption was enabled still read back
+	if got, err := k.Open("legacy"); err != nil || got != "legacy" {
+		t.Errorf("Open(plaintext) = %q, %v", got, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old := testKeyring(t, "k1", "k1", "k2")
+	sealed, err := old.Seal("token")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rotated, err := old.WithPrimary("k2")
+	if err != nil {
+		t.Fatalf("WithPrimary: %v", err)
+	}
+	if !rotated.NeedsReseal(sealed) || old.NeedsReseal(sealed) {
+		t.Errorf("NeedsReseal should only be true under the new primary key")
+	}
+	if got, err := rotated.Open(sealed); err != nil || got != "token" {
+		t.Errorf("Open after rotation = %q, %v", got, err)
+	}
+
+	retired := testKeyring(t, "k2", "k2")
+	if _, err := retired.Open(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Open with retired key = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyring_Tampered(t *testing.T) {
+	k := testKeyring(t, "k1", "k1", "k2")
+	sealed, err := k.Seal("token")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	// relabelling the wrapped key with another ID must not decrypt
+	relabelled := strings.Replace(sealed, prefix+"k1:", prefix+"k2:", 1)
+	if _, err := k.Open(relabelled); err == nil {
+		t.Error("Open accepted a value with a swapped key ID")
+	}
+	if _, err := k.Open(prefix + "k1:nope"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Open(malformed) = %v, want ErrMalformed", err)
+	}
+}
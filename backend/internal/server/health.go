@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pin-app/pin/internal/metrics"
+	"github.com/pin-app/pin/internal/version"
+	"github.com/pin-app/pin/migrations"
+)
+
+// readyTimeout bounds each readiness check so a hung database can't hang
+// the probe
+const readyTimeout = 2 * time.Second
+
+func (s *Server) registerOpsRoutes() {
+	s.router.HandleFunc("/healthz", "GET", s.healthz)
+	s.router.HandleFunc("/readyz", "GET", s.readyz)
+	s.router.HandleFunc("/version", "GET", s.version)
+}
+
+// ServeMetrics serves /metrics to scrapers that send token as a bearer
+// token. Without a token /metrics isn't served at all, since request counts
+// and pool stats are nobody else's business.
+func (s *Server) ServeMetrics(token string) {
+	if token == "" {
+		return
+	}
+
+	want := []byte("Bearer " + token)
+	s.router.HandleFunc("/metrics", "GET", func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid metrics token"})
+			return
+		}
+		metrics.Default.ServeHTTP(w, r)
+	})
+}
+
+// healthz answers as long as the process is serving requests
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyz reports whether this instance can take traffic: the database
+// answers and is fully migrated, and uploads can be written. Each check is
+// just "ok" or "failed"; the reason is logged, since it can name hosts and
+// paths the probe's caller shouldn't see.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	checks := map[string]string{}
+	ready := true
+	check := func(name string, err error) {
+		if err != nil {
+			s.logger.Error("readiness check failed", "check", name, "error", err)
+			checks[name] = "failed"
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	if s.db == nil {
+		checks["database"] = "not configured"
+	} else {
+		err := s.db.PingContext(ctx)
+		check("database", err)
+		if err == nil {
+			check("migrations", s.checkMigrations(ctx))
+		}
+	}
+	if s.staticDir != "" {
+		check("uploads", checkWritable(s.staticDir))
+	}
+
+	status, state := http.StatusOK, "ready"
+	if !ready {
+		status, state = http.StatusServiceUnavailable, "not ready"
+	}
+	WriteJSON(w, status, map[string]interface{}{
+		"status": state,
+		"checks": checks,
+	})
+}
+
+// version reports the commit the binary was built from and the migration
+// version compiled into it
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	latest, err := migrations.Latest()
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to read migration version"})
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"commit":            version.Revision(),
+		"migration_version": latest,
+	})
+}
+
+// checkMigrations fails until the database has every migration this build
+// expects, or if one stopped partway
+func (s *Server) checkMigrations(ctx context.Context) error {
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	applied, dirty, err := migrations.Applied(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", applied)
+	}
+	if applied < latest {
+		return fmt.Errorf("at version %d, want %d", applied, latest)
+	}
+	return nil
+}
+
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("not writable: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
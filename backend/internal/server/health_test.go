@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pin-app/pin/migrations"
+)
+
+func TestServer_Healthz(t *testing.T) {
+	s := New()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /healthz status = %v, want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestServer_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		dir        string
+		wantStatus int
+		wantCheck  string
+	}{
+		{"writable uploads", t.TempDir(), http.StatusOK, "ok"},
+		{"missing uploads", filepath.Join(t.TempDir(), "missing"), http.StatusServiceUnavailable, "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.ServeStatic("/uploads/", tt.dir)
+
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("GET /readyz status = %v, want %v: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+
+			var resp struct {
+				Checks map[string]string `json:"checks"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			if resp.Checks["database"] != "not configured" {
+				t.Errorf("database check = %q, want %q", resp.Checks["database"], "not configured")
+			}
+			if resp.Checks["uploads"] != tt.wantCheck {
+				t.Errorf("uploads check = %q, want %q", resp.Checks["uploads"], tt.wantCheck)
+			}
+			// the error, and the path in it, only go to the log
+			if strings.Contains(rr.Body.String(), tt.dir) {
+				t.Errorf("GET /readyz leaked the upload dir: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_Version(t *testing.T) {
+	s := New()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/version", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /version status = %v, want %v", rr.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Commit           string `json:"commit"`
+		MigrationVersion uint   `json:"migration_version"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+
+	latest, _ := migrations.Latest()
+	if resp.MigrationVersion != latest || latest == 0 {
+		t.Errorf("migration_version = %d, want %d", resp.MigrationVersion, latest)
+	}
+	if resp.Commit == "" {
+		t.Errorf("commit is empty")
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s := New()
+	s.ServeMetrics("scrape-token")
+	// metrics are process wide, so use a route no other run has counted
+	prefix := "/api/posts-" + uuid.NewString()[:8]
+	s.GetRouter().HandleFunc(prefix+"/{id}", "GET", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+	})
+
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", prefix+"/123", nil))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", prefix+"/456", nil))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %v, want %v", rr.Code, http.StatusOK)
+	}
+
+	// both posts land in the one series for the pattern
+	body := rr.Body.String()
+	want := `pin_http_requests_total{route="` + prefix + `/{id}",method="GET",status="404"} 2`
+	if !strings.Contains(body, want+"\n") {
+		t.Errorf("missing %q in:\n%s", want, body)
+	}
+	if strings.Contains(body, prefix+"/123") {
+		t.Errorf("raw path used as a label:\n%s", body)
+	}
+}
+
+func TestServer_MetricsAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		header     string
+		wantStatus int
+	}{
+		{"no token configured", "", "Bearer anything", http.StatusNotFound},
+		{"no header", "scrape-token", "", http.StatusUnauthorized},
+		{"wrong token", "scrape-token", "Bearer nope", http.StatusUnauthorized},
+		{"right token", "scrape-token", "Bearer scrape-token", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.ServeMetrics(tt.token)
+
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("GET /metrics status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
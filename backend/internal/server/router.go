@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Router dispatches requests by method and path pattern. A pattern is made
+// of literal segments and {name} parameters; when more than one pattern
+// matches a path the one with a literal segment earliest wins, so
+// /api/users/search beats /api/users/{id} whatever order they were added in.
+// Parameters are available through r.PathValue and PathUUID.
+type Router struct {
+	routes []*route
+}
+
+// Middleware wraps a handler, like AuthMiddleware.RequireAuth
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+type route struct {
+	pattern  string
+	segments []string
+	handlers map[string]http.HandlerFunc
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// HandleFunc registers handler for method requests to pattern. Registering
+// the same method and pattern twice panics.
+func (r *Router) HandleFunc(pattern, method string, handler http.HandlerFunc) {
+	method = strings.ToUpper(method)
+
+	for _, rt := range r.routes {
+		if rt.pattern != pattern {
+			continue
+		}
+		if _, exists := rt.handlers[method]; exists {
+			panic(fmt.Sprintf("server: %s %s registered twice", method, pattern))
+		}
+		rt.handlers[method] = handler
+		return
+	}
+
+	r.routes = append(r.routes, &route{
+		pattern:  pattern,
+		segments: strings.Split(pattern, "/"),
+		handlers: map[string]http.HandlerFunc{method: handler},
+	})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return r.routes[i].moreSpecific(r.routes[j])
+	})
+}
+
+// Group returns a group of routes under prefix that all go through mw, the
+// first one outermost
+func (r *Router) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{router: r, prefix: prefix, middleware: mw}
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(req.URL.Path, "/")
+
+	var allowed []string
+	for _, rt := range r.routes {
+		values, ok := rt.match(parts)
+		if !ok {
+			continue
+		}
+
+		handler := rt.handlers[req.Method]
+		if handler == nil && req.Method == http.MethodHead {
+			handler = rt.handlers[http.MethodGet]
+		}
+		if handler == nil {
+			allowed = append(allowed, rt.methods()...)
+			continue
+		}
+
+		for name, value := range values {
+			req.SetPathValue(name, value)
+		}
+		req.Pattern = rt.pattern
+		handler(w, req)
+		return
+	}
+
+	if allowed == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Allow", allowHeader(allowed))
+	if req.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	WriteJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "Method not allowed"})
+}
+
+// match reports whether the path split into parts fits the route, and the
+// parameter values if so
+func (rt *route) match(parts []string) (map[string]string, bool) {
+	if len(parts) != len(rt.segments) {
+		return nil, false
+	}
+
+	var values map[string]string
+	for i, segment := range rt.segments {
+		name, isParam := paramName(segment)
+		switch {
+		case !isParam && segment != parts[i]:
+			return nil, false
+		case isParam && parts[i] == "":
+			return nil, false
+		case isParam:
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = parts[i]
+		}
+	}
+
+	return values, true
+}
+
+// moreSpecific orders routes so that the first one matching a path is the
+// one with the earliest literal segment
+func (rt *route) moreSpecific(other *route) bool {
+	if len(rt.segments) != len(other.segments) {
+		return len(rt.segments) > len(other.segments)
+	}
+	for i := range rt.segments {
+		_, param := paramName(rt.segments[i])
+		_, otherParam := paramName(other.segments[i])
+		if param != otherParam {
+			return otherParam
+		}
+	}
+	return false
+}
+
+// methods lists what the route answers to, including the HEAD and OPTIONS
+// the router adds
+func (rt *route) methods() []string {
+	methods := []string{http.MethodOptions}
+	for method := range rt.handlers {
+		methods = append(methods, method)
+		if method == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	return methods
+}
+
+func paramName(segment string) (string, bool) {
+	if len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+func allowHeader(methods []string) string {
+	seen := make(map[string]bool, len(methods))
+	unique := methods[:0]
+	for _, method := range methods {
+		if !seen[method] {
+			seen[method] = true
+			unique = append(unique, method)
+		}
+	}
+	sort.Strings(unique)
+	return strings.Join(unique, ", ")
+}
+
+// Group registers routes under a shared prefix and middleware
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []Middleware
+}
+
+// HandleFunc registers handler for prefix+pattern, wrapped in the group's
+// middleware
+func (g *Group) HandleFunc(pattern, method string, handler http.HandlerFunc) {
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		handler = g.middleware[i](handler)
+	}
+	g.router.HandleFunc(g.prefix+pattern, method, handler)
+}
+
+// Group nests a group under this one; its middleware runs inside this
+// group's
+func (g *Group) Group(prefix string, mw ...Middleware) *Group {
+	middleware := make([]Middleware, 0, len(g.middleware)+len(mw))
+	middleware = append(middleware, g.middleware...)
+	middleware = append(middleware, mw...)
+	return &Group{router: g.router, prefix: g.prefix + prefix, middleware: middleware}
+}
+
+// PathUUID parses the named path parameter as a UUID
+func PathUUID(r *http.Request, name string) (uuid.UUID, error) {
+	return uuid.Parse(r.PathValue(name))
+}
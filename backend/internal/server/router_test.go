@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+func TestRouter_MostSpecificMatch(t *testing.T) {
+	router := NewRouter()
+	// registered param first so order can't be what decides
+	router.HandleFunc("/api/users/{id}", "GET", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + r.PathValue("id")))
+	})
+	router.HandleFunc("/api/users/search", "GET", respond("search"))
+	router.HandleFunc("/api/{kind}/search", "GET", respond("kind search"))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/users/search", "search"},
+		{"/api/users/abc", "user abc"},
+		{"/api/places/search", "kind search"},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+		if rr.Body.String() != tt.want {
+			t.Errorf("GET %s = %q, want %q", tt.path, rr.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestRouter_Pattern(t *testing.T) {
+	router := NewRouter()
+	var pattern string
+	router.HandleFunc("/api/posts/{id}/likes", "POST", func(w http.ResponseWriter, r *http.Request) {
+		pattern = r.Pattern
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/posts/123/likes", nil))
+	if pattern != "/api/posts/{id}/likes" {
+		t.Errorf("Pattern = %q, want %q", pattern, "/api/posts/{id}/likes")
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/api/posts/{id}", "GET", respond("get"))
+	router.HandleFunc("/api/posts/{id}", "DELETE", respond("delete"))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/posts/123", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %v, want %v", rr.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := rr.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q", allow)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/api/posts/123", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS status = %v, want %v", rr.Code, http.StatusNoContent)
+	}
+	if rr.Header().Get("Allow") == "" {
+		t.Errorf("OPTIONS didn't set Allow")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/nothing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown path status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRouter_Head(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/api/places", "GET", respond("places"))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("HEAD", "/api/places", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("HEAD status = %v, want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRouter_Group(t *testing.T) {
+	router := NewRouter()
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next(w, r)
+			}
+		}
+	}
+
+	api := router.Group("/api", mw("outer"))
+	api.Group("/admin", mw("inner")).HandleFunc("/users", "GET", respond("users"))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/users", nil))
+	if rr.Body.String() != "users" {
+		t.Fatalf("GET /api/admin/users = %q", rr.Body.String())
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("middleware order = %v, want [outer inner]", order)
+	}
+}
+
+func TestRouter_DuplicateRoute(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/api/places", "GET", respond("a"))
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a route twice didn't panic")
+		}
+	}()
+	router.HandleFunc("/api/places", "GET", respond("b"))
+}
@@ -7,10 +7,12 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/pin-app/pin/internal/metrics"
 )
 
 type Server struct {
@@ -18,27 +20,21 @@ type Server struct {
 	logger        *slog.Logger
 	db            *sql.DB
 	staticPrefix  string
+	staticDir     string
 	staticHandler http.Handler
 }
 
-type Router struct {
-	routes map[string]map[string]http.HandlerFunc
-}
-
 func New() *Server {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level:     slog.LevelInfo,
 		AddSource: true,
 	}))
 
-	router := &Router{
-		routes: make(map[string]map[string]http.HandlerFunc),
-	}
-
 	s := &Server{
-		router: router,
+		router: NewRouter(),
 		logger: logger,
 	}
+	s.registerOpsRoutes()
 
 	return s
 }
@@ -49,15 +45,13 @@ func NewWithDB(db *sql.DB) *Server {
 		AddSource: true,
 	}))
 
-	router := &Router{
-		routes: make(map[string]map[string]http.HandlerFunc),
-	}
-
 	s := &Server{
-		router: router,
+		router: NewRouter(),
 		logger: logger,
 		db:     db,
 	}
+	s.registerOpsRoutes()
+	metrics.RegisterDBStats(db)
 
 	return s
 }
@@ -84,76 +78,10 @@ func (s *Server) ServeStatic(prefix, dir string) {
 	}
 
 	s.staticPrefix = prefix
+	s.staticDir = dir
 	s.staticHandler = http.StripPrefix(prefix, http.FileServer(http.Dir(dir)))
 }
 
-func (r *Router) HandleFunc(pattern, method string, handler http.HandlerFunc) {
-	if r.routes[pattern] == nil {
-		r.routes[pattern] = make(map[string]http.HandlerFunc)
-	}
-	r.routes[pattern][method] = handler
-}
-
-func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	path := req.URL.Path
-	method := req.Method
-
-	// First try exact match
-	if routes, exists := r.routes[path]; exists {
-		if handler, exists := routes[method]; exists {
-			handler(w, req)
-			return
-		}
-	}
-
-	// Then try pattern matching for routes with parameters
-	for pattern, routes := range r.routes {
-		if r.matchesPattern(pattern, path) {
-			if handler, exists := routes[method]; exists {
-				handler(w, req)
-				return
-			}
-		}
-	}
-
-	http.NotFound(w, req)
-}
-
-func (r *Router) matchesPattern(pattern, path string) bool {
-	// Simple pattern matching for {id} parameters
-	// Convert pattern like "/api/users/{id}/stats" to regex-like matching
-	patternParts := strings.Split(pattern, "/")
-	pathParts := strings.Split(path, "/")
-
-	if len(patternParts) != len(pathParts) {
-		return false
-	}
-
-	for i, patternPart := range patternParts {
-		// Handle empty parts (leading/trailing slashes)
-		if patternPart == "" && pathParts[i] == "" {
-			continue
-		}
-		if patternPart == "" || pathParts[i] == "" {
-			return false
-		}
-		// Check if this is a parameter like {id}
-		if strings.HasPrefix(patternPart, "{") && strings.HasSuffix(patternPart, "}") {
-			// This is a parameter, match any non-empty string
-			if pathParts[i] == "" {
-				return false
-			}
-			continue
-		}
-		// Exact match for non-parameter parts
-		if patternPart != pathParts[i] {
-			return false
-		}
-	}
-
-	return true
-}
-
 func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -161,7 +89,7 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 }
 
 func (s *Server) middleware(next http.Handler) http.Handler {
-	return s.corsMiddleware(s.loggingMiddleware(next))
+	return s.corsMiddleware(s.loggingMiddleware(s.metricsMiddleware(next)))
 }
 
 func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
@@ -186,11 +114,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
+		// preflights go on to the router, which answers with the
+		// path's allowed methods
 		next.ServeHTTP(w, r)
 	})
 }
@@ -224,6 +149,39 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// metricsMiddleware counts and times requests by the pattern the router
+// matched, so /api/posts/{id} is one series however many posts there are
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		// the router sets Pattern on this same request once it matches
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metricMethod(r.Method)
+		status := strconv.Itoa(wrapped.statusCode)
+		metrics.HTTPRequests.Inc(route, method, status)
+		metrics.HTTPDuration.Observe(time.Since(start).Seconds(), route, method, status)
+	})
+}
+
+// metricMethod keeps made up methods from adding series
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -233,3 +191,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the connection, so handlers can
+// move their write deadline
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
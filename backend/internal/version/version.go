@@ -0,0 +1,25 @@
+// Package version describes the build that's running
+package version
+
+import "runtime/debug"
+
+// Commit is the git SHA the binary was built from, set at build time with
+//
+//	go build -ldflags "-X github.com/pin-app/pin/internal/version.Commit=$(git rev-parse HEAD)"
+var Commit string
+
+// Revision returns Commit, falling back to the VCS stamp go build adds when
+// built inside a checkout, then "unknown"
+func Revision() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,5 @@
+-- places created before this migration have no owner and can only be
+-- changed by running SQL directly
+ALTER TABLE places ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_places_created_by ON places(created_by);
@@ -0,0 +1,6 @@
+-- plain text + check instead of an enum so adding a role later doesn't need
+-- an ALTER TYPE
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role) WHERE role <> 'user';
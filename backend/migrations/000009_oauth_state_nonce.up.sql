@@ -0,0 +1,2 @@
+-- oidc nonce sent with the auth request and checked against the id_token
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS nonce TEXT;
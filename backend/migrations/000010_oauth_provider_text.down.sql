@@ -0,0 +1,9 @@
+CREATE TYPE oauth_provider AS ENUM ('google', 'apple');
+
+-- anything signed in through a provider the enum doesn't know about can't
+-- be kept
+DELETE FROM oauth_states WHERE provider NOT IN ('google', 'apple');
+DELETE FROM oauth_accounts WHERE provider NOT IN ('google', 'apple');
+
+ALTER TABLE oauth_states ALTER COLUMN provider TYPE oauth_provider USING provider::oauth_provider;
+ALTER TABLE oauth_accounts ALTER COLUMN provider TYPE oauth_provider USING provider::oauth_provider;
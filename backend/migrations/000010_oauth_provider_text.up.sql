@@ -0,0 +1,6 @@
+-- providers come from config now, so an enum would need a migration for
+-- every new one. names are validated in the app instead
+ALTER TABLE oauth_accounts ALTER COLUMN provider TYPE TEXT USING provider::text;
+ALTER TABLE oauth_states ALTER COLUMN provider TYPE TEXT USING provider::text;
+
+DROP TYPE IF EXISTS oauth_provider;
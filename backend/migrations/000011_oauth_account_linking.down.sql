@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_oauth_accounts_provider_identity;
+
+-- only one row per identity can survive the old constraint
+DELETE FROM oauth_accounts WHERE deleted_at IS NOT NULL;
+ALTER TABLE oauth_accounts ADD CONSTRAINT oauth_accounts_provider_provider_id_key UNIQUE (provider, provider_id);
+
+ALTER TABLE oauth_states DROP COLUMN IF EXISTS user_id;
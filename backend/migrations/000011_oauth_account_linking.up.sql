@@ -0,0 +1,9 @@
+-- set when the state was created by a signed in user linking another
+-- provider, rather than someone signing in
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+
+-- unlinking soft deletes, so the old unique constraint would stop anyone
+-- from ever linking that identity again
+ALTER TABLE oauth_accounts DROP CONSTRAINT IF EXISTS oauth_accounts_provider_provider_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_accounts_provider_identity
+    ON oauth_accounts(provider, provider_id) WHERE deleted_at IS NULL;
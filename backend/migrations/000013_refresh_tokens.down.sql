@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_session_refresh_tokens_session_id;
+DROP TABLE IF EXISTS session_refresh_tokens;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS access_expires_at;
@@ -0,0 +1,20 @@
+-- a session is now a token family: session_token is a short lived access
+-- token and expires_at is when the family dies unless it keeps refreshing
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS access_expires_at TIMESTAMPTZ;
+
+-- existing tokens keep working until their old 30 day expiry, they just
+-- can't be refreshed
+UPDATE sessions SET access_expires_at = expires_at WHERE access_expires_at IS NULL;
+ALTER TABLE sessions ALTER COLUMN access_expires_at SET NOT NULL;
+
+-- every refresh token a family has ever had. used ones are kept so that
+-- presenting one again can be caught as theft
+CREATE TABLE IF NOT EXISTS session_refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_refresh_tokens_session_id ON session_refresh_tokens(session_id);
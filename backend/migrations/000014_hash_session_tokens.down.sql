@@ -0,0 +1,8 @@
+-- digests can't be turned back into tokens, so every session has to go
+UPDATE sessions SET deleted_at = NOW() WHERE deleted_at IS NULL;
+DELETE FROM session_refresh_tokens;
+
+ALTER TABLE session_refresh_tokens RENAME COLUMN token_hash TO token;
+
+ALTER INDEX IF EXISTS idx_sessions_token_hash RENAME TO idx_sessions_token;
+ALTER TABLE sessions RENAME COLUMN token_hash TO session_token;
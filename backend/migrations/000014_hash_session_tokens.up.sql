@@ -0,0 +1,9 @@
+-- only digests of bearer tokens are kept from here on, so a dump of the
+-- table doesn't hand out logins. existing tokens are hashed in place rather
+-- than thrown away, so nobody gets signed out by the deploy
+ALTER TABLE sessions RENAME COLUMN session_token TO token_hash;
+UPDATE sessions SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+ALTER INDEX IF EXISTS idx_sessions_token RENAME TO idx_sessions_token_hash;
+
+ALTER TABLE session_refresh_tokens RENAME COLUMN token TO token_hash;
+UPDATE session_refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
@@ -0,0 +1,16 @@
+-- tokens users create for scripts. like sessions only a digest is stored;
+-- token_hint is the last few characters so people can tell them apart
+CREATE TABLE IF NOT EXISTS access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    token_hash TEXT UNIQUE NOT NULL,
+    token_hint TEXT NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON access_tokens(user_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,15 @@
+-- one-time sign in links sent by email. like sessions, only a digest of the
+-- token is kept. rows stick around after use so requests can be counted
+-- for rate limiting
+CREATE TABLE IF NOT EXISTS magic_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    ip_address TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_magic_links_email_created_at ON magic_links(LOWER(email), created_at);
+CREATE INDEX IF NOT EXISTS idx_magic_links_ip_created_at ON magic_links(ip_address, created_at);
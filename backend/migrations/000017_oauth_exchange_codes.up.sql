@@ -0,0 +1,13 @@
+-- the callback hands the app a one-time code instead of tokens, so tokens
+-- never sit in a redirect url or browser history. the app trades the code
+-- for a session with POST /api/auth/exchange
+CREATE TABLE IF NOT EXISTS oauth_exchange_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code_hash TEXT UNIQUE NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_exchange_codes_expires_at ON oauth_exchange_codes(expires_at);
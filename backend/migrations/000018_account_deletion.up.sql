@@ -0,0 +1,7 @@
+-- deleting an account only schedules it. the user can cancel until the
+-- grace period is up, then a background job erases the row and everything
+-- hanging off it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_follows_pending;
+DELETE FROM follows WHERE status = 'pending';
+ALTER TABLE follows DROP COLUMN IF EXISTS status;
+ALTER TABLE users DROP COLUMN IF EXISTS is_private;
@@ -0,0 +1,9 @@
+-- private accounts only show their posts and follow lists to approved
+-- followers. following one creates a pending request the owner approves
+-- or denies; existing follows count as approved
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE follows ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'accepted'
+    CHECK (status IN ('pending', 'accepted'));
+
+CREATE INDEX IF NOT EXISTS idx_follows_pending ON follows(following_id, created_at) WHERE status = 'pending';
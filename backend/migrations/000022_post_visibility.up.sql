@@ -0,0 +1,4 @@
+-- who can see a post: anyone, the author's approved followers, or only the
+-- author. existing posts stay public
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'only_me'));
@@ -0,0 +1 @@
+ALTER TABLE oauth_accounts DROP COLUMN IF EXISTS provider_email_verified;
@@ -0,0 +1,4 @@
+-- whether the provider vouched for provider_email. only verified identities
+-- count as proof of an address when a new sign in is merged into an account.
+-- existing rows can't be told apart after the fact, so they start unverified
+ALTER TABLE oauth_accounts ADD COLUMN IF NOT EXISTS provider_email_verified BOOLEAN NOT NULL DEFAULT FALSE;